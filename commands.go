@@ -0,0 +1,348 @@
+package main
+
+import (
+	"assignment/internal/clock"
+	"assignment/internal/config"
+	"assignment/internal/controller"
+	"assignment/internal/eventlog"
+	"assignment/internal/order"
+	"assignment/internal/scenario"
+	"assignment/internal/simulator"
+	"assignment/internal/stats"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runScenario implements "run --scenario file": it executes the scenario
+// against a fresh controller and returns a non-zero exit code on unmet expectations
+func runScenario(args []string) int {
+	var path string
+	cfg, err := parseConfig(args, func(cfg *config.Config, configPath *string) *flag.FlagSet {
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		fs.Usage = usageFor(fs, "--scenario <file> [flags]")
+		configFlag(fs, configPath)
+		fs.StringVar(&path, "scenario", "", "scenario file to execute")
+		fs.StringVar(&cfg.ResultPath, "result", cfg.ResultPath, "file the order log is written to")
+		fs.StringVar(&cfg.ResultFormat, "result-format", cfg.ResultFormat, "order log format: text, json or csv")
+		fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "stdout log format: text or json")
+		fs.DurationVar(&cfg.ProcessingTime, "processing-time", cfg.ProcessingTime, "time a bot takes per order")
+		fs.DurationVar(&cfg.PickupTimeout, "pickup-timeout", cfg.PickupTimeout, "mark completed orders ABANDONED when not collected within this (0 disables)")
+		fs.DurationVar(&cfg.ReleaseLeadTime, "release-lead-time", cfg.ReleaseLeadTime, "release scheduled orders into the queue this long before they are due")
+		fs.IntVar(&cfg.MaxPendingVIP, "max-pending-vip", cfg.MaxPendingVIP, "VIP orders allowed to wait before new ones are turned away (0 for no limit)")
+		fs.IntVar(&cfg.MaxPendingNormal, "max-pending-normal", cfg.MaxPendingNormal, "Normal orders allowed to wait before new ones are turned away (0 for no limit)")
+		fs.IntVar(&cfg.MaxPending, "max-pending", cfg.MaxPending, "orders allowed to wait before new ones are turned away (0 for no limit)")
+		fs.BoolVar(&cfg.Waitlist, "waitlist", cfg.Waitlist, "waitlist new orders over the limits instead of rejecting them")
+		fs.IntVar(&cfg.MaxWaitlist, "max-waitlist", cfg.MaxWaitlist, "orders allowed on the waitlist before new ones are rejected (0 for no limit)")
+		fs.IntVar(&cfg.BusyThreshold, "busy-threshold", cfg.BusyThreshold, "percentage of the queue limits at which the kitchen is reported busy (0 disables)")
+		fs.BoolVar(&cfg.CheckInvariants, "check-invariants", cfg.CheckInvariants, "verify scheduling rules on every transition and report violations")
+		return fs
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	if path == "" {
+		fmt.Println("run: --scenario is required")
+		return 2
+	}
+	s, err := scenario.Load(path)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	timestampFormat = cfg.TimestampFormat
+	openResultFile(cfg.ResultPath, cfg.ResultFormat)
+	defer closeResultFile()
+
+	clk := clock.Real()
+	ctrl := controller.NewController(newLogger(cfg.LogFormat, clk), controller.WithConfig(cfg.Controller()), controller.WithClock(clk), controller.WithArchive(newArchive(cfg)))
+	recordEvents(ctrl)
+	result := s.Run(ctrl)
+
+	for _, failure := range result.Failures {
+		fmt.Printf("FAIL %s\n", failure)
+	}
+	if !result.Passed() {
+		fmt.Printf("Scenario failed: %d of %d expectations unmet\n", len(result.Failures), len(s.Expectations))
+		return 1
+	}
+	fmt.Printf("Scenario passed: %d expectations met\n", len(s.Expectations))
+	return 0
+}
+
+// runSimulation implements "simulate": it runs a deterministic simulation with
+// a virtual clock and prints summary statistics
+func runSimulation(args []string) int {
+	sim := simulator.DefaultConfig()
+	var logPath string
+	cfg, err := parseConfig(args, func(cfg *config.Config, configPath *string) *flag.FlagSet {
+		fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+		fs.Usage = usageFor(fs, "[flags]")
+		configFlag(fs, configPath)
+		fs.Int64Var(&sim.Seed, "seed", sim.Seed, "random seed for the arrival process")
+		fs.DurationVar(&sim.Duration, "duration", sim.Duration, "simulated period during which orders arrive")
+		fs.Float64Var(&sim.OrdersPerHour, "rate", sim.OrdersPerHour, "mean orders per hour")
+		fs.Float64Var(&sim.VIPRatio, "vip-ratio", sim.VIPRatio, "fraction of orders that are VIP (0-1)")
+		fs.IntVar(&cfg.Bots, "bots", cfg.Bots, fmt.Sprintf("number of bots (%d when unset)", sim.Bots))
+		fs.DurationVar(&cfg.ProcessingTime, "processing-time", cfg.ProcessingTime, "time a bot takes per order")
+		fs.StringVar(&logPath, "log", "", "write the simulated event log to this file")
+		return fs
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	sim.ProcessingTime = cfg.ProcessingTime
+	if cfg.Bots > 0 {
+		sim.Bots = cfg.Bots
+	}
+
+	result, err := simulator.Run(sim)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	if logPath != "" {
+		if err := os.WriteFile(logPath, []byte(strings.Join(result.Log, "\n")+"\n"), 0644); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
+	fmt.Printf("Simulated %s with %d bots, %.0f orders/hour, %.0f%% VIP (seed %d)\n",
+		sim.Duration, sim.Bots, sim.OrdersPerHour, sim.VIPRatio*100, sim.Seed)
+	for _, line := range result.Summary() {
+		fmt.Println("  " + line)
+	}
+	return 0
+}
+
+// readResultLog parses the log named by the first positional argument,
+// falling back to the default result file. Text lines are read with the
+// configured timestamp format.
+func readResultLog(fs *flag.FlagSet, cfg config.Config) ([]eventlog.Entry, string, error) {
+	path := defaultResultPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, path, err
+	}
+	defer f.Close()
+
+	entries, err := eventlog.ParseLayout(f, cfg.TimestampFormat)
+	return entries, path, err
+}
+
+// runReplay implements "replay": it prints the lifecycle of every order in a
+// result log and the inconsistencies found, exiting with 1 if there are any
+func runReplay(args []string) int {
+	var fs *flag.FlagSet
+	cfg, err := parseConfig(args, func(cfg *config.Config, configPath *string) *flag.FlagSet {
+		fs = flag.NewFlagSet("replay", flag.ExitOnError)
+		fs.Usage = usageFor(fs, "[flags] [result-file]")
+		configFlag(fs, configPath)
+		return fs
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	timestampFormat = cfg.TimestampFormat
+
+	entries, path, err := readResultLog(fs, cfg)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	fmt.Printf("Replaying %s (%d events)\n", path, len(entries))
+	for _, t := range eventlog.Timelines(entries) {
+		fmt.Printf("\n%s Order #%d\n", t.OrderType, t.OrderID)
+		for _, e := range t.Entries {
+			fmt.Printf("  [%s] %s\n", e.Time.Format(timestampFormat), describe(e))
+		}
+	}
+
+	issues := eventlog.Check(entries)
+	if skipped := eventlog.Skipped(entries); len(skipped) > 0 {
+		fmt.Printf("\nChecks skipped (%d):\n", len(skipped))
+		for _, s := range skipped {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+	if len(issues) == 0 {
+		fmt.Println("\nNo inconsistencies found.")
+		return 0
+	}
+	fmt.Printf("\nInconsistencies (%d):\n", len(issues))
+	for _, issue := range issues {
+		fmt.Printf("  %s\n", issue)
+	}
+	return 1
+}
+
+// describe returns a short description of an order event for the replay timeline
+func describe(e eventlog.Entry) string {
+	switch e.Type {
+	case controller.EventOrderCreated:
+		return "created"
+	case controller.EventOrderStarted:
+		return fmt.Sprintf("started by Bot #%d", e.BotID)
+	case controller.EventOrderCompleted:
+		return fmt.Sprintf("completed by Bot #%d", e.BotID)
+	case controller.EventOrderRequeued:
+		return fmt.Sprintf("returned to PENDING (Bot #%d removed)", e.BotID)
+	case controller.EventOrderScheduled:
+		return "scheduled, " + e.Detail
+	case controller.EventOrderReleased:
+		return "released into the queue"
+	case controller.EventOrderWaitlisted:
+		return "waitlisted, " + e.Detail
+	case controller.EventOrderAdmitted:
+		return "admitted from the waitlist"
+	case controller.EventOrderHeld:
+		return "put on hold"
+	case controller.EventOrderResumed:
+		return "resumed"
+	case controller.EventOrderMovedUp, controller.EventOrderMovedDown:
+		return "moved " + e.Detail
+	case controller.EventOrderPromoted:
+		return "promoted to VIP"
+	case controller.EventOrderDemoted:
+		return "demoted to Normal"
+	default:
+		return string(e.Type)
+	}
+}
+
+// runReport implements "report": it prints order counts and wait/cook statistics from a result log
+func runReport(args []string) int {
+	var fs *flag.FlagSet
+	cfg, err := parseConfig(args, func(cfg *config.Config, configPath *string) *flag.FlagSet {
+		fs = flag.NewFlagSet("report", flag.ExitOnError)
+		fs.Usage = usageFor(fs, "[flags] [result-file]")
+		configFlag(fs, configPath)
+		return fs
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	entries, path, err := readResultLog(fs, cfg)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	orders := eventlog.Orders(entries)
+	counts := make(map[order.OrderStatus]int)
+	types := make(map[order.OrderType]int)
+	requeues := 0
+	for _, o := range orders {
+		counts[o.Status]++
+		types[o.Type]++
+	}
+	for _, e := range entries {
+		if e.Type == controller.EventOrderRequeued {
+			requeues++
+		}
+	}
+
+	// Throughput is measured up to the last event in the log
+	report := stats.Report{}
+	if len(entries) > 0 {
+		report = stats.Build(orders, entries[len(entries)-1].Time)
+	}
+
+	fmt.Printf("Report for %s\n", path)
+	fmt.Printf("\nTotal Orders: %d (VIP %d, Normal %d)\n", len(orders), types[order.VIP], types[order.Normal])
+	fmt.Printf("  SCHEDULED: %d\n", counts[order.SCHEDULED])
+	fmt.Printf("  WAITLISTED: %d\n", counts[order.WAITLISTED])
+	fmt.Printf("  PENDING: %d\n", counts[order.PENDING])
+	fmt.Printf("  HELD: %d\n", counts[order.HELD])
+	fmt.Printf("  PROCESSING: %d\n", counts[order.PROCESSING])
+	fmt.Printf("  COMPLETE: %d\n", counts[order.COMPLETE])
+	fmt.Printf("  COLLECTED: %d\n", counts[order.COLLECTED])
+	fmt.Printf("  ABANDONED: %d\n", counts[order.ABANDONED])
+	fmt.Printf("  Requeued: %d\n", requeues)
+
+	fmt.Printf("\nWait Time Summary (completed orders):\n")
+	printDistribution("VIP", report.Wait[order.VIP])
+	printDistribution("Normal", report.Wait[order.Normal])
+	printDistribution("All", report.AllWait)
+
+	fmt.Printf("\nCook Time Summary:\n")
+	printDistribution("All", report.Cook)
+
+	fmt.Printf("\nThroughput: %.2f orders/min\n", report.ThroughputPerMinute)
+	return 0
+}
+
+// runExport implements "export": it converts a result log in any format to
+// JSON lines or CSV
+func runExport(args []string) int {
+	var fs *flag.FlagSet
+	var format, outPath, date string
+	cfg, err := parseConfig(args, func(cfg *config.Config, configPath *string) *flag.FlagSet {
+		fs = flag.NewFlagSet("export", flag.ExitOnError)
+		fs.Usage = usageFor(fs, "[flags] [result-file]")
+		configFlag(fs, configPath)
+		fs.StringVar(&format, "format", eventlog.FormatCSV, "output format: json or csv")
+		fs.StringVar(&outPath, "out", "", "write to this file instead of stdout")
+		fs.StringVar(&date, "date", "", "date (YYYY-MM-DD) of a text log whose timestamps only record the time of day")
+		return fs
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	var day time.Time
+	if date != "" {
+		if day, err = time.Parse("2006-01-02", date); err != nil {
+			fmt.Printf("export: invalid --date %q, expected YYYY-MM-DD\n", date)
+			return 2
+		}
+	}
+
+	entries, _, err := readResultLog(fs, cfg)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	out := os.Stdout
+	if outPath != "" {
+		if out, err = os.Create(outPath); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		defer out.Close()
+	}
+	w, err := eventlog.NewWriter(out, format)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	for _, e := range entries {
+		// Text log times fall on year zero until anchored to a date
+		if !day.IsZero() && e.Time.Year() == 0 {
+			e.Time = e.Time.AddDate(day.Year(), int(day.Month())-1, day.Day()-1)
+		}
+		if err := w.Write(e.Event); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,225 @@
+package archive
+
+import (
+	"assignment/internal/order"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Config is the retention policy of an archive. Zero values disable a limit.
+type Config struct {
+	MaxOrders int           // keep at most this many orders, evicting the oldest archived first
+	MaxAge    time.Duration // evict orders archived longer ago than this
+}
+
+// Record is a snapshot of an archived order
+type Record struct {
+	order.View
+	BotID      int // bot that completed the order
+	ArchivedAt time.Time
+	History    []order.Transition
+	Cursor     int // position in the archive, used to continue a query after this record
+}
+
+// entry is an archived order. The order stays live so it can still change
+// state, e.g. an abandoned order collected late.
+type entry struct {
+	seq        int
+	order      *order.Order
+	archivedAt time.Time
+}
+
+// Store holds orders that have left the kitchen and the pickup area.
+// A store is not safe for concurrent use: the controller that owns it uses
+// it only while holding its lock.
+type Store struct {
+	cfg     Config
+	export  io.Writer
+	entries []entry // in archival order
+	byID    map[int]*order.Order
+	seq     int
+}
+
+// NewStore creates an empty archive. When export is not nil, orders are
+// written to it as JSON lines before they are evicted.
+func NewStore(cfg Config, export io.Writer) *Store {
+	return &Store{
+		cfg:    cfg,
+		export: export,
+		byID:   make(map[int]*order.Order),
+	}
+}
+
+// Add archives an order and applies the retention policy. It returns an
+// error only when exporting evicted orders fails; they are evicted anyway.
+func (s *Store) Add(o *order.Order, now time.Time) error {
+	s.seq++
+	s.entries = append(s.entries, entry{seq: s.seq, order: o, archivedAt: now})
+	s.byID[o.ID] = o
+	return s.Prune(now)
+}
+
+// Get returns the archived order with the given ID, or nil
+func (s *Store) Get(id int) *order.Order {
+	return s.byID[id]
+}
+
+// Len returns the number of archived orders
+func (s *Store) Len() int {
+	return len(s.entries)
+}
+
+// Prune evicts the orders the retention policy no longer allows, oldest first
+func (s *Store) Prune(now time.Time) error {
+	evict := 0
+	if s.cfg.MaxOrders > 0 && len(s.entries) > s.cfg.MaxOrders {
+		evict = len(s.entries) - s.cfg.MaxOrders
+	}
+	if s.cfg.MaxAge > 0 {
+		for evict < len(s.entries) && now.Sub(s.entries[evict].archivedAt) > s.cfg.MaxAge {
+			evict++
+		}
+	}
+	if evict == 0 {
+		return nil
+	}
+
+	var err error
+	if s.export != nil {
+		err = s.write(s.entries[:evict])
+	}
+	// Reslice rather than copy, so a full archive evicts in constant time;
+	// cleared entries let the evicted orders be collected
+	for i, e := range s.entries[:evict] {
+		delete(s.byID, e.order.ID)
+		s.entries[i] = entry{}
+	}
+	s.entries = s.entries[evict:]
+	return err
+}
+
+// Query selects archived orders. Zero values match everything.
+type Query struct {
+	From  time.Time         // completed at or after
+	To    time.Time         // completed before
+	Types []order.OrderType // any of these types
+	BotID int               // completed by this bot
+	After int               // cursor: only records after this one (Record.Cursor or Page.Next)
+	Limit int               // page size; 0 returns every match
+}
+
+// Page is one page of query results, oldest archived first
+type Page struct {
+	Records []Record
+	Next    int // cursor for the following page, 0 when there are no more
+}
+
+// Query returns the archived orders matching q
+func (s *Store) Query(q Query) Page {
+	page := Page{Records: make([]Record, 0)}
+	for _, e := range s.entries {
+		if e.seq <= q.After || !q.matches(e) {
+			continue
+		}
+		if q.Limit > 0 && len(page.Records) == q.Limit {
+			page.Next = page.Records[len(page.Records)-1].Cursor
+			break
+		}
+		page.Records = append(page.Records, e.record())
+	}
+	return page
+}
+
+func (q Query) matches(e entry) bool {
+	o := e.order
+	if !q.From.IsZero() && o.CompletedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !o.CompletedAt.Before(q.To) {
+		return false
+	}
+	if len(q.Types) > 0 {
+		found := false
+		for _, t := range q.Types {
+			found = found || o.Type == t
+		}
+		if !found {
+			return false
+		}
+	}
+	return q.BotID == 0 || completedBy(o) == q.BotID
+}
+
+func (e entry) record() Record {
+	return Record{
+		View:       e.order.View(),
+		BotID:      completedBy(e.order),
+		ArchivedAt: e.archivedAt,
+		History:    e.order.History(),
+		Cursor:     e.seq,
+	}
+}
+
+// completedBy returns the bot that completed the order, 0 if none did
+func completedBy(o *order.Order) int {
+	history := o.History()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].To == order.COMPLETE {
+			return history[i].BotID
+		}
+	}
+	return 0
+}
+
+// exported is the JSON form of an evicted order
+type exported struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Status      string       `json:"status"`
+	BotID       int          `json:"bot_id,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	StartedAt   time.Time    `json:"started_at"`
+	CompletedAt time.Time    `json:"completed_at"`
+	CollectedAt *time.Time   `json:"collected_at,omitempty"`
+	ArchivedAt  time.Time    `json:"archived_at"`
+	History     []transition `json:"history"`
+}
+
+type transition struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	BotID  int       `json:"bot_id,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// write exports entries as JSON lines
+func (s *Store) write(entries []entry) error {
+	enc := json.NewEncoder(s.export)
+	for _, e := range entries {
+		r := e.record()
+		out := exported{
+			ID:          r.ID,
+			Type:        r.Type.String(),
+			Status:      r.Status.String(),
+			BotID:       r.BotID,
+			CreatedAt:   r.CreatedAt,
+			StartedAt:   r.StartedAt,
+			CompletedAt: r.CompletedAt,
+			ArchivedAt:  r.ArchivedAt,
+			History:     make([]transition, len(r.History)),
+		}
+		if !r.CollectedAt.IsZero() {
+			out.CollectedAt = &r.CollectedAt
+		}
+		for i, t := range r.History {
+			out.History[i] = transition{From: t.From.String(), To: t.To.String(), At: t.At, BotID: t.BotID, Reason: t.Reason}
+		}
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("archive: export Order #%d: %w", r.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"assignment/internal/order"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// cooked returns an order completed by the given bot at epoch + done
+func cooked(id int, typ order.OrderType, botID int, done time.Duration) *order.Order {
+	o := order.NewOrderAt(id, typ, epoch)
+	o.Transition(order.PROCESSING, epoch.Add(done-10*time.Second), botID, "picked up")
+	o.Transition(order.COMPLETE, epoch.Add(done), botID, "cooked")
+	o.Transition(order.COLLECTED, epoch.Add(done+time.Minute), 0, "collected by customer")
+	return o
+}
+
+func ids(records []Record) []int {
+	out := make([]int, len(records))
+	for i, r := range records {
+		out[i] = r.ID
+	}
+	return out
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQueryFilters(t *testing.T) {
+	s := NewStore(Config{}, nil)
+	s.Add(cooked(1, order.Normal, 1, 10*time.Second), epoch)
+	s.Add(cooked(2, order.VIP, 2, 20*time.Second), epoch)
+	s.Add(cooked(3, order.Normal, 2, 30*time.Second), epoch)
+	s.Add(cooked(4, order.VIP, 1, 40*time.Second), epoch)
+
+	tests := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		{"all", Query{}, []int{1, 2, 3, 4}},
+		{"type", Query{Types: []order.OrderType{order.VIP}}, []int{2, 4}},
+		{"bot", Query{BotID: 2}, []int{2, 3}},
+		{"range", Query{From: epoch.Add(20 * time.Second), To: epoch.Add(40 * time.Second)}, []int{2, 3}},
+		{"combined", Query{Types: []order.OrderType{order.Normal}, BotID: 1}, []int{1}},
+	}
+	for _, tt := range tests {
+		if got := ids(s.Query(tt.q).Records); !equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	r := s.Query(Query{BotID: 2}).Records[0]
+	if r.BotID != 2 || r.Status != order.COLLECTED || len(r.History) != 3 {
+		t.Errorf("Unexpected record %+v", r)
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	s := NewStore(Config{}, nil)
+	for id := 1; id <= 5; id++ {
+		s.Add(cooked(id, order.Normal, 1, time.Duration(id)*time.Second), epoch)
+	}
+
+	got := make([]int, 0)
+	q := Query{Limit: 2}
+	for pages := 1; ; pages++ {
+		page := s.Query(q)
+		got = append(got, ids(page.Records)...)
+		if page.Next == 0 {
+			if pages != 3 {
+				t.Errorf("Expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		q.After = page.Next
+	}
+	if !equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected every order once across pages, got %v", got)
+	}
+
+	// An exactly full last page has no next cursor
+	if page := s.Query(Query{After: 3, Limit: 2}); page.Next != 0 || len(page.Records) != 2 {
+		t.Errorf("Expected a final page of 2, got %+v", page)
+	}
+}
+
+func TestRetentionByCount(t *testing.T) {
+	var export bytes.Buffer
+	s := NewStore(Config{MaxOrders: 2}, &export)
+	for id := 1; id <= 3; id++ {
+		if err := s.Add(cooked(id, order.Normal, 1, time.Duration(id)*time.Second), epoch); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if s.Len() != 2 || s.Get(1) != nil || s.Get(3) == nil {
+		t.Errorf("Expected the oldest order evicted, got %v", ids(s.Query(Query{}).Records))
+	}
+
+	// The evicted order was exported first
+	lines := strings.Split(strings.TrimSpace(export.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected one exported order, got %q", export.String())
+	}
+	var out struct {
+		ID      int    `json:"id"`
+		Status  string `json:"status"`
+		BotID   int    `json:"bot_id"`
+		History []struct {
+			To string `json:"to"`
+		} `json:"history"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &out); err != nil {
+		t.Fatalf("Invalid export line %q: %v", lines[0], err)
+	}
+	if out.ID != 1 || out.Status != "COLLECTED" || out.BotID != 1 || len(out.History) != 3 {
+		t.Errorf("Unexpected export %+v", out)
+	}
+}
+
+func TestRetentionByAge(t *testing.T) {
+	s := NewStore(Config{MaxAge: time.Hour}, nil)
+	s.Add(cooked(1, order.Normal, 1, time.Second), epoch)
+	s.Add(cooked(2, order.Normal, 1, time.Second), epoch.Add(30*time.Minute))
+
+	s.Prune(epoch.Add(time.Hour))
+	if s.Len() != 2 {
+		t.Errorf("Expected orders kept up to their maximum age, got %d", s.Len())
+	}
+
+	s.Prune(epoch.Add(time.Hour + time.Minute))
+	if s.Len() != 1 || s.Get(2) == nil {
+		t.Errorf("Expected only order #2 left, got %v", ids(s.Query(Query{}).Records))
+	}
+}
@@ -0,0 +1,279 @@
+package autoscaler
+
+import (
+	"assignment/internal/bot"
+	"assignment/internal/clock"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Target is the part of the controller the autoscaler observes and drives
+type Target interface {
+	BotCount() int
+	PendingCount() int
+	OldestPendingWait() time.Duration
+	NewestBotIdle() bool
+	AddBot() (bot.View, error)
+	RemoveBot() (bot.View, error)
+}
+
+// Config holds the scaling bounds and thresholds.
+// Scale-up and scale-down thresholds are kept apart (hysteresis) so the
+// bot count does not flap around a single value.
+type Config struct {
+	MinBots int
+	MaxBots int
+
+	// Add a bot when pending depth reaches ScaleUpDepth or the oldest
+	// pending order has waited at least ScaleUpWait
+	ScaleUpDepth int
+	ScaleUpWait  time.Duration
+
+	// Remove a bot only when pending depth is at most ScaleDownDepth and
+	// the oldest pending order has waited less than ScaleDownWait
+	ScaleDownDepth int
+	ScaleDownWait  time.Duration
+
+	// Minimum time between two scaling actions in the same direction
+	ScaleUpCooldown   time.Duration
+	ScaleDownCooldown time.Duration
+
+	// How often Run evaluates the controller
+	Interval time.Duration
+}
+
+// DefaultConfig returns a configuration suitable for a single store
+func DefaultConfig() Config {
+	return Config{
+		MinBots:           1,
+		MaxBots:           5,
+		ScaleUpDepth:      4,
+		ScaleUpWait:       30 * time.Second,
+		ScaleDownDepth:    0,
+		ScaleDownWait:     5 * time.Second,
+		ScaleUpCooldown:   10 * time.Second,
+		ScaleDownCooldown: 30 * time.Second,
+		Interval:          time.Second,
+	}
+}
+
+// Validate checks that the configuration is consistent
+func (c Config) Validate() error {
+	if c.MinBots < 0 {
+		return fmt.Errorf("autoscaler: min bots must not be negative, got %d", c.MinBots)
+	}
+	if c.MaxBots < c.MinBots {
+		return fmt.Errorf("autoscaler: max bots (%d) must be >= min bots (%d)", c.MaxBots, c.MinBots)
+	}
+	if c.ScaleDownDepth >= c.ScaleUpDepth {
+		return fmt.Errorf("autoscaler: scale-down depth (%d) must be below scale-up depth (%d)", c.ScaleDownDepth, c.ScaleUpDepth)
+	}
+	if c.ScaleDownWait >= c.ScaleUpWait {
+		return fmt.Errorf("autoscaler: scale-down wait (%s) must be below scale-up wait (%s)", c.ScaleDownWait, c.ScaleUpWait)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("autoscaler: interval must be positive, got %s", c.Interval)
+	}
+	return nil
+}
+
+// Action is the outcome of a scaling evaluation
+type Action int
+
+const (
+	None Action = iota
+	ScaleUp
+	ScaleDown
+)
+
+// String returns a string representation of the action
+func (a Action) String() string {
+	switch a {
+	case ScaleUp:
+		return "SCALE_UP"
+	case ScaleDown:
+		return "SCALE_DOWN"
+	default:
+		return "NONE"
+	}
+}
+
+// Decision describes what the autoscaler did and why
+type Decision struct {
+	Action Action
+	Reason string
+}
+
+// Autoscaler adds and removes bots based on queue depth and wait time
+type Autoscaler struct {
+	mu            sync.Mutex
+	cfg           Config
+	target        Target
+	logger        func(string)
+	clock         clock.Clock
+	layout        string // timestamp layout of log lines
+	lastScaleUp   time.Time
+	lastScaleDown time.Time
+}
+
+// Option customises an autoscaler created by New
+type Option func(*Autoscaler)
+
+// WithClock times evaluations with the given clock instead of the system
+// time. Pass the controller's clock so both follow the same time.
+func WithClock(clk clock.Clock) Option {
+	return func(a *Autoscaler) {
+		a.clock = clk
+	}
+}
+
+// WithTimestampFormat sets the time layout of log lines, HH:MM:SS by
+// default. Pass the controller's layout so both logs line up.
+func WithTimestampFormat(layout string) Option {
+	return func(a *Autoscaler) {
+		a.layout = layout
+	}
+}
+
+// New creates an autoscaler for the given target
+func New(cfg Config, target Target, logger func(string), opts ...Option) (*Autoscaler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	a := &Autoscaler{
+		cfg:    cfg,
+		target: target,
+		logger: logger,
+		clock:  clock.Real(),
+		layout: "15:04:05",
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
+}
+
+// Run evaluates the target every Interval until ctx is cancelled
+func (a *Autoscaler) Run(ctx context.Context) {
+	stop := a.Start()
+	defer stop()
+	<-ctx.Done()
+}
+
+// Start evaluates the target every Interval, as measured by the
+// autoscaler's clock, until the returned function is called. It does not
+// block: with a virtual clock, evaluations run as the clock is advanced.
+func (a *Autoscaler) Start() (stop func()) {
+	var mu sync.Mutex
+	stopped := false
+	var timer clock.Timer
+	var tick func()
+	tick = func() {
+		a.Evaluate(a.clock.Now())
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			timer = a.clock.AfterFunc(a.cfg.Interval, tick)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	timer = a.clock.AfterFunc(a.cfg.Interval, tick)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		timer.Stop()
+	}
+}
+
+// Evaluate inspects the target once and applies at most one scaling action.
+// Every action taken is logged together with its reason.
+func (a *Autoscaler) Evaluate(now time.Time) Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d := a.decide(now)
+	switch d.Action {
+	case ScaleUp:
+		b, err := a.target.AddBot()
+		if err != nil {
+			return Decision{Action: None, Reason: err.Error()}
+		}
+		a.lastScaleUp = now
+		a.log(now, fmt.Sprintf("added Bot #%d (%s)", b.ID, d.Reason))
+	case ScaleDown:
+		if _, err := a.target.RemoveBot(); err != nil {
+			return Decision{Action: None, Reason: err.Error()}
+		}
+		a.lastScaleDown = now
+		a.log(now, fmt.Sprintf("removed a bot (%s)", d.Reason))
+	}
+	return d
+}
+
+// decide works out the scaling action without applying it
+func (a *Autoscaler) decide(now time.Time) Decision {
+	bots := a.target.BotCount()
+	depth := a.target.PendingCount()
+	wait := a.target.OldestPendingWait()
+
+	// Bounds are enforced regardless of cooldowns
+	if bots < a.cfg.MinBots {
+		return Decision{ScaleUp, fmt.Sprintf("bots %d below minimum %d", bots, a.cfg.MinBots)}
+	}
+	if bots > a.cfg.MaxBots {
+		return a.scaleDown(fmt.Sprintf("bots %d above maximum %d", bots, a.cfg.MaxBots))
+	}
+
+	if depth >= a.cfg.ScaleUpDepth || wait >= a.cfg.ScaleUpWait {
+		if bots >= a.cfg.MaxBots {
+			return Decision{None, "at maximum bots"}
+		}
+		if !a.lastScaleUp.IsZero() && now.Sub(a.lastScaleUp) < a.cfg.ScaleUpCooldown {
+			return Decision{None, "scale-up cooldown"}
+		}
+		if depth >= a.cfg.ScaleUpDepth {
+			return Decision{ScaleUp, fmt.Sprintf("pending %d >= %d", depth, a.cfg.ScaleUpDepth)}
+		}
+		return Decision{ScaleUp, fmt.Sprintf("oldest wait %s >= %s", wait.Round(time.Second), a.cfg.ScaleUpWait)}
+	}
+
+	if depth <= a.cfg.ScaleDownDepth && wait < a.cfg.ScaleDownWait {
+		if bots <= a.cfg.MinBots {
+			return Decision{None, "at minimum bots"}
+		}
+		// Do not undo a recent scale-up, and space out scale-downs
+		if !a.lastScaleUp.IsZero() && now.Sub(a.lastScaleUp) < a.cfg.ScaleDownCooldown {
+			return Decision{None, "scale-down cooldown"}
+		}
+		if !a.lastScaleDown.IsZero() && now.Sub(a.lastScaleDown) < a.cfg.ScaleDownCooldown {
+			return Decision{None, "scale-down cooldown"}
+		}
+		return a.scaleDown(fmt.Sprintf("pending %d <= %d and oldest wait %s < %s",
+			depth, a.cfg.ScaleDownDepth, wait.Round(time.Second), a.cfg.ScaleDownWait))
+	}
+
+	return Decision{None, "within thresholds"}
+}
+
+// scaleDown decides to remove a bot for the given reason. Removing a bot
+// cooking an order would restart that order, so the scale-down waits until
+// the newest bot, the one removed, is idle.
+func (a *Autoscaler) scaleDown(reason string) Decision {
+	if !a.target.NewestBotIdle() {
+		return Decision{None, "newest bot busy"}
+	}
+	return Decision{ScaleDown, reason}
+}
+
+func (a *Autoscaler) log(now time.Time, msg string) {
+	if a.logger == nil {
+		return
+	}
+	a.logger(fmt.Sprintf("[%s] Autoscaler %s", now.Format(a.layout), msg))
+}
@@ -0,0 +1,216 @@
+package autoscaler
+
+import (
+	"assignment/internal/bot"
+	"assignment/internal/clock"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTarget struct {
+	bots    int
+	pending int
+	wait    time.Duration
+	busy    bool // the newest bot is cooking
+}
+
+func (f *fakeTarget) BotCount() int                    { return f.bots }
+func (f *fakeTarget) PendingCount() int                { return f.pending }
+func (f *fakeTarget) OldestPendingWait() time.Duration { return f.wait }
+func (f *fakeTarget) NewestBotIdle() bool              { return !f.busy }
+
+func (f *fakeTarget) AddBot() (bot.View, error) {
+	f.bots++
+	return bot.NewBot(f.bots).View(), nil
+}
+
+func (f *fakeTarget) RemoveBot() (bot.View, error) {
+	if f.bots == 0 {
+		return bot.View{}, errors.New("no bots available")
+	}
+	f.bots--
+	return bot.NewBot(f.bots + 1).View(), nil
+}
+
+func newTestAutoscaler(t *testing.T, target *fakeTarget, logs *[]string) *Autoscaler {
+	a, err := New(DefaultConfig(), target, func(s string) {
+		*logs = append(*logs, s)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return a
+}
+
+func TestEnforcesMinimum(t *testing.T) {
+	target := &fakeTarget{}
+	logs := make([]string, 0)
+	a := newTestAutoscaler(t, target, &logs)
+
+	d := a.Evaluate(time.Now())
+	if d.Action != ScaleUp {
+		t.Errorf("Expected SCALE_UP below minimum, got %v", d.Action)
+	}
+	if target.bots != 1 {
+		t.Errorf("Expected 1 bot, got %d", target.bots)
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "below minimum") {
+		t.Errorf("Expected scaling decision to be logged with reason, got %v", logs)
+	}
+}
+
+func TestScaleUpOnDepthWithCooldown(t *testing.T) {
+	target := &fakeTarget{bots: 1, pending: 6}
+	logs := make([]string, 0)
+	a := newTestAutoscaler(t, target, &logs)
+	now := time.Now()
+
+	if d := a.Evaluate(now); d.Action != ScaleUp {
+		t.Fatalf("Expected SCALE_UP on depth, got %v (%s)", d.Action, d.Reason)
+	}
+
+	// Still deep, but within cooldown
+	if d := a.Evaluate(now.Add(5 * time.Second)); d.Action != None {
+		t.Errorf("Expected no action during cooldown, got %v", d.Action)
+	}
+
+	if d := a.Evaluate(now.Add(11 * time.Second)); d.Action != ScaleUp {
+		t.Errorf("Expected SCALE_UP after cooldown, got %v", d.Action)
+	}
+	if target.bots != 3 {
+		t.Errorf("Expected 3 bots, got %d", target.bots)
+	}
+}
+
+func TestScaleUpOnWait(t *testing.T) {
+	target := &fakeTarget{bots: 1, pending: 1, wait: 45 * time.Second}
+	logs := make([]string, 0)
+	a := newTestAutoscaler(t, target, &logs)
+
+	d := a.Evaluate(time.Now())
+	if d.Action != ScaleUp || !strings.Contains(d.Reason, "oldest wait") {
+		t.Errorf("Expected SCALE_UP on wait time, got %v (%s)", d.Action, d.Reason)
+	}
+}
+
+func TestRespectsMaximum(t *testing.T) {
+	target := &fakeTarget{bots: 5, pending: 20}
+	logs := make([]string, 0)
+	a := newTestAutoscaler(t, target, &logs)
+
+	if d := a.Evaluate(time.Now()); d.Action != None {
+		t.Errorf("Expected no action at maximum, got %v", d.Action)
+	}
+	if len(logs) != 0 {
+		t.Errorf("Expected nothing logged, got %v", logs)
+	}
+}
+
+func TestHysteresis(t *testing.T) {
+	// Between the scale-down and scale-up thresholds nothing should happen
+	target := &fakeTarget{bots: 3, pending: 2, wait: 10 * time.Second}
+	logs := make([]string, 0)
+	a := newTestAutoscaler(t, target, &logs)
+
+	if d := a.Evaluate(time.Now()); d.Action != None {
+		t.Errorf("Expected no action between thresholds, got %v", d.Action)
+	}
+}
+
+func TestScaleDownAfterCooldown(t *testing.T) {
+	target := &fakeTarget{bots: 1, pending: 6}
+	logs := make([]string, 0)
+	a := newTestAutoscaler(t, target, &logs)
+	now := time.Now()
+
+	a.Evaluate(now)
+	target.pending = 0
+
+	// A quiet queue right after scaling up should not remove the bot again
+	if d := a.Evaluate(now.Add(5 * time.Second)); d.Action != None {
+		t.Errorf("Expected no action right after scale-up, got %v", d.Action)
+	}
+
+	if d := a.Evaluate(now.Add(31 * time.Second)); d.Action != ScaleDown {
+		t.Errorf("Expected SCALE_DOWN after cooldown, got %v (%s)", d.Action, d.Reason)
+	}
+	if target.bots != 1 {
+		t.Errorf("Expected 1 bot, got %d", target.bots)
+	}
+
+	// Never below the minimum
+	if d := a.Evaluate(now.Add(2 * time.Minute)); d.Action != None {
+		t.Errorf("Expected no action at minimum, got %v", d.Action)
+	}
+}
+
+func TestScaleDownWaitsForIdleBot(t *testing.T) {
+	target := &fakeTarget{bots: 3, busy: true}
+	logs := make([]string, 0)
+	a, err := New(DefaultConfig(), target, func(s string) { logs = append(logs, s) }, WithTimestampFormat("15:04"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Removing the newest bot now would restart the order it is cooking
+	if d := a.Evaluate(now); d.Action != None || d.Reason != "newest bot busy" {
+		t.Errorf("Expected no action while the newest bot is busy, got %v (%s)", d.Action, d.Reason)
+	}
+	target.busy = false
+	if d := a.Evaluate(now.Add(time.Second)); d.Action != ScaleDown {
+		t.Errorf("Expected SCALE_DOWN once the newest bot is idle, got %v (%s)", d.Action, d.Reason)
+	}
+	if target.bots != 2 || len(logs) != 1 || !strings.HasPrefix(logs[0], "[12:00] ") {
+		t.Errorf("Expected one bot removed and logged with the given layout, got %d bots, %v", target.bots, logs)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBots = 0
+	if _, err := New(cfg, &fakeTarget{}, nil); err == nil {
+		t.Error("Expected error when max bots is below min bots")
+	}
+
+	cfg = DefaultConfig()
+	cfg.ScaleDownDepth = cfg.ScaleUpDepth
+	if _, err := New(cfg, &fakeTarget{}, nil); err == nil {
+		t.Error("Expected error when thresholds overlap")
+	}
+}
+
+func TestStartFollowsClock(t *testing.T) {
+	v := clock.NewVirtual(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	target := &fakeTarget{bots: 1, pending: 6}
+	logs := make([]string, 0)
+	a, err := New(DefaultConfig(), target, func(s string) { logs = append(logs, s) }, WithClock(v))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stop := a.Start()
+	if target.bots != 1 {
+		t.Errorf("Expected no evaluation before the first interval, got %d bots", target.bots)
+	}
+
+	// One scale-up per cooldown while the queue stays deep
+	v.Advance(25 * time.Second)
+	if target.bots != 4 {
+		t.Errorf("Expected 4 bots after 25 virtual seconds, got %d", target.bots)
+	}
+	if len(logs) != 3 || !strings.HasPrefix(logs[0], "[12:00:01]") {
+		t.Errorf("Expected scaling logged at virtual times, got %v", logs)
+	}
+
+	stop()
+	v.Advance(time.Minute)
+	if target.bots != 4 {
+		t.Errorf("Expected no evaluation after stop, got %d bots", target.bots)
+	}
+	if _, ok := v.Next(); ok {
+		t.Error("Expected no timer left after stop")
+	}
+}
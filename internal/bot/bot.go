@@ -2,7 +2,7 @@ package bot
 
 import (
 	"assignment/internal/order"
-	"context"
+	"fmt"
 	"time"
 )
 
@@ -14,70 +14,177 @@ const (
 	PROCESSING
 )
 
-// Bot represents a cooking bot that processes orders
+// ProcessingTime is how long a bot takes to process one order
+const ProcessingTime = 10 * time.Second
+
+// Bot represents a cooking bot that processes orders.
+// A bot is not safe for concurrent use: the controller that owns it changes
+// and reads it only while holding its lock, and hands out View copies.
 type Bot struct {
-	ID          int
-	Status      BotStatus
+	ID           int
+	Status       BotStatus
 	CurrentOrder *order.Order
-	CreatedAt   time.Time
-	ctx         context.Context
-	cancel      context.CancelFunc
-	stopChan    chan struct{}
+	CreatedAt    time.Time
+
+	// Productivity counters
+	removedAt         time.Time
+	busySince         time.Time
+	busyTime          time.Duration
+	ordersCompleted   int
+	ordersInterrupted int
+}
+
+// Stats is a snapshot of a bot's productivity
+type Stats struct {
+	ID                int
+	CreatedAt         time.Time
+	RemovedAt         time.Time // zero while the bot is active
+	Uptime            time.Duration
+	BusyTime          time.Duration
+	IdleTime          time.Duration
+	OrdersCompleted   int
+	OrdersInterrupted int
 }
 
 // NewBot creates a new bot with the given ID
 func NewBot(id int) *Bot {
-	ctx, cancel := context.WithCancel(context.Background())
+	return NewBotAt(id, time.Now())
+}
+
+// NewBotAt creates a new bot created at the given time
+func NewBotAt(id int, createdAt time.Time) *Bot {
 	return &Bot{
 		ID:        id,
 		Status:    IDLE,
-		CreatedAt: time.Now(),
-		ctx:       ctx,
-		cancel:    cancel,
-		stopChan:  make(chan struct{}),
+		CreatedAt: createdAt,
+	}
+}
+
+// View is an immutable snapshot of a bot
+type View struct {
+	ID        int
+	Status    BotStatus
+	OrderID   int // order being processed, 0 when idle
+	CreatedAt time.Time
+}
+
+// View returns a snapshot of the bot's current state
+func (b *Bot) View() View {
+	v := View{ID: b.ID, Status: b.Status, CreatedAt: b.CreatedAt}
+	if b.CurrentOrder != nil {
+		v.OrderID = b.CurrentOrder.ID
 	}
+	return v
+}
+
+// IsIdle returns true if the bot was idle when the snapshot was taken
+func (v View) IsIdle() bool {
+	return v.Status == IDLE
+}
+
+// IsProcessing returns true if the bot was processing an order when the snapshot was taken
+func (v View) IsProcessing() bool {
+	return v.Status == PROCESSING
 }
 
-// StartProcessing assigns an order to the bot and starts processing it
-// Processing takes 10 seconds. Returns true if processing completed,
-// false if it was cancelled.
-func (b *Bot) StartProcessing(o *order.Order) bool {
+// Assign hands an order to the bot, which starts processing it at the given time.
+// The caller is responsible for finishing or interrupting it later.
+// The order must be PENDING and the bot idle.
+func (b *Bot) Assign(o *order.Order, at time.Time) error {
+	if b.IsProcessing() {
+		return fmt.Errorf("bot #%d is already processing Order #%d", b.ID, b.CurrentOrder.ID)
+	}
+	if err := o.Transition(order.PROCESSING, at, b.ID, "picked up"); err != nil {
+		return err
+	}
 	b.CurrentOrder = o
 	b.Status = PROCESSING
-	o.SetProcessing()
+	b.busySince = at
+	return nil
+}
+
+// Finish completes the current order at the given time and returns it
+func (b *Bot) Finish(at time.Time) (*order.Order, error) {
+	o := b.CurrentOrder
+	if o == nil {
+		return nil, fmt.Errorf("bot #%d is not processing an order", b.ID)
+	}
+	if err := o.Transition(order.COMPLETE, at, b.ID, "cooked"); err != nil {
+		return nil, err
+	}
+	b.release(at, true)
+	return o, nil
+}
+
+// Interrupt stops processing the current order, returns it to PENDING with
+// the given reason and returns it
+func (b *Bot) Interrupt(at time.Time, reason string) (*order.Order, error) {
+	o := b.CurrentOrder
+	if o == nil {
+		return nil, fmt.Errorf("bot #%d is not processing an order", b.ID)
+	}
+	if err := o.Transition(order.PENDING, at, b.ID, reason); err != nil {
+		return nil, err
+	}
+	b.release(at, false)
+	return o, nil
+}
+
+// release makes the bot idle and records the end of a processing period
+func (b *Bot) release(at time.Time, completed bool) {
+	b.Status = IDLE
+	b.CurrentOrder = nil
 	
-	// Process for 10 seconds, but check for cancellation
-	select {
-	case <-time.After(10 * time.Second):
-		// Processing completed
-		o.SetComplete()
-		b.Status = IDLE
-		b.CurrentOrder = nil
-		return true
-	case <-b.ctx.Done():
-		// Processing was cancelled
-		o.SetPending()
-		b.Status = IDLE
-		b.CurrentOrder = nil
-		return false
-	}
-}
-
-// Stop cancels the bot's current processing and signals the bot to stop
-func (b *Bot) Stop() {
-	if b.Status == PROCESSING && b.CurrentOrder != nil {
-		b.cancel()
-	}
-	// Signal the bot to stop processing
-	select {
-	case b.stopChan <- struct{}{}:
-	default:
+	end := at
+	if !b.removedAt.IsZero() && b.removedAt.Before(end) {
+		end = b.removedAt
+	}
+	b.busyTime += end.Sub(b.busySince)
+	b.busySince = time.Time{}
+	if completed {
+		b.ordersCompleted++
+	} else {
+		b.ordersInterrupted++
 	}
 }
 
-// ShouldStop checks if the bot should stop processing
-func (b *Bot) ShouldStop() <-chan struct{} {
-	return b.stopChan
+// Retire marks the bot as removed so its uptime stops accumulating
+func (b *Bot) Retire(at time.Time) {
+	if b.removedAt.IsZero() {
+		b.removedAt = at
+	}
+}
+
+// Stats returns the bot's productivity as of now (or as of removal)
+func (b *Bot) Stats(now time.Time) Stats {
+	end := now
+	if !b.removedAt.IsZero() {
+		end = b.removedAt
+	}
+	busy := b.busyTime
+	if !b.busySince.IsZero() && end.After(b.busySince) {
+		busy += end.Sub(b.busySince)
+	}
+	uptime := end.Sub(b.CreatedAt)
+	
+	return Stats{
+		ID:                b.ID,
+		CreatedAt:         b.CreatedAt,
+		RemovedAt:         b.removedAt,
+		Uptime:            uptime,
+		BusyTime:          busy,
+		IdleTime:          uptime - busy,
+		OrdersCompleted:   b.ordersCompleted,
+		OrdersInterrupted: b.ordersInterrupted,
+	}
+}
+
+// Utilisation returns the fraction of uptime the bot spent processing orders
+func (s Stats) Utilisation() float64 {
+	if s.Uptime <= 0 {
+		return 0
+	}
+	return float64(s.BusyTime) / float64(s.Uptime)
 }
 
 // IsIdle returns true if the bot is currently idle
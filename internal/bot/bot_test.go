@@ -2,6 +2,7 @@ package bot
 
 import (
 	"assignment/internal/order"
+	"errors"
 	"testing"
 	"time"
 )
@@ -26,102 +27,147 @@ func TestNewBot(t *testing.T) {
 	}
 }
 
-func TestStartProcessing(t *testing.T) {
+func TestIsIdle(t *testing.T) {
+	bot := NewBot(1)
+	
+	if !bot.IsIdle() {
+		t.Error("Expected new bot to be idle")
+	}
+}
+
+func TestIsProcessing(t *testing.T) {
 	bot := NewBot(1)
 	o := order.NewOrder(1, order.Normal)
 	
-	start := time.Now()
-	completed := bot.StartProcessing(o)
-	duration := time.Since(start)
+	bot.Assign(o, time.Now())
 	
-	if !completed {
-		t.Error("Expected processing to complete")
+	if !bot.IsProcessing() {
+		t.Error("Expected bot to be processing")
 	}
 	
-	if duration < 10*time.Second {
-		t.Errorf("Expected processing to take at least 10 seconds, took %v", duration)
-	}
+	bot.Finish(time.Now())
 	
-	if o.Status != order.COMPLETE {
-		t.Errorf("Expected order status COMPLETE, got %v", o.Status)
+	if bot.IsProcessing() {
+		t.Error("Expected bot to be idle after completion")
 	}
-	
-	if bot.Status != IDLE {
-		t.Errorf("Expected bot status IDLE after completion, got %v", bot.Status)
+}
+
+func TestBotStatusString(t *testing.T) {
+	if IDLE.String() != "IDLE" {
+		t.Errorf("Expected 'IDLE', got '%s'", IDLE.String())
 	}
 	
-	if bot.CurrentOrder != nil {
-		t.Error("Expected CurrentOrder to be nil after completion")
+	if PROCESSING.String() != "PROCESSING" {
+		t.Errorf("Expected 'PROCESSING', got '%s'", PROCESSING.String())
 	}
 }
 
-func TestStopProcessing(t *testing.T) {
+func TestRetiredStatsStopAccumulating(t *testing.T) {
 	bot := NewBot(1)
-	o := order.NewOrder(1, order.Normal)
-	
-	// Start processing in a goroutine
-	done := make(chan bool)
-	go func() {
-		completed := bot.StartProcessing(o)
-		done <- completed
-	}()
+	bot.Retire(time.Now())
 	
-	// Wait a bit, then stop
-	time.Sleep(2 * time.Second)
-	bot.Stop()
+	first := bot.Stats(time.Now())
+	second := bot.Stats(time.Now().Add(time.Minute))
 	
-	// Wait for processing to finish
-	completed := <-done
-	
-	if completed {
-		t.Error("Expected processing to be cancelled")
+	if first.RemovedAt.IsZero() {
+		t.Error("Expected RemovedAt to be set")
 	}
 	
-	if o.Status != order.PENDING {
-		t.Errorf("Expected order status PENDING after cancellation, got %v", o.Status)
+	if first.Uptime != second.Uptime {
+		t.Errorf("Expected uptime to stop after retirement, got %v then %v", first.Uptime, second.Uptime)
 	}
 	
-	if bot.Status != IDLE {
-		t.Errorf("Expected bot status IDLE after cancellation, got %v", bot.Status)
+	if second.IdleTime != second.Uptime {
+		t.Errorf("Expected idle bot to be idle for its whole uptime, got %+v", second)
 	}
 }
 
-func TestIsIdle(t *testing.T) {
-	bot := NewBot(1)
+func TestAssignAndFinish(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	bot := NewBotAt(1, start)
+	o := order.NewOrderAt(1, order.VIP, start)
 	
-	if !bot.IsIdle() {
-		t.Error("Expected new bot to be idle")
+	bot.Assign(o, start.Add(2*time.Second))
+	if !bot.IsProcessing() || bot.CurrentOrder != o || o.Status != order.PROCESSING {
+		t.Fatal("Expected bot to be processing the assigned order")
+	}
+	
+	finished, err := bot.Finish(start.Add(12 * time.Second))
+	if err != nil || finished != o || o.Status != order.COMPLETE {
+		t.Errorf("Expected order to be completed, got %v", o.Status)
+	}
+	
+	if o.CookDuration() != ProcessingTime {
+		t.Errorf("Expected cook duration %v, got %v", ProcessingTime, o.CookDuration())
+	}
+	
+	stats := bot.Stats(start.Add(20 * time.Second))
+	if stats.BusyTime != 10*time.Second || stats.IdleTime != 10*time.Second || stats.OrdersCompleted != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
 	}
 }
 
-func TestIsProcessing(t *testing.T) {
-	bot := NewBot(1)
-	o := order.NewOrder(1, order.Normal)
+func TestInterrupt(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	bot := NewBotAt(1, start)
+	o := order.NewOrderAt(1, order.Normal, start)
 	
-	// Start processing in a goroutine
-	go bot.StartProcessing(o)
+	bot.Assign(o, start)
+	bot.Retire(start.Add(4 * time.Second))
+	interrupted, err := bot.Interrupt(start.Add(5*time.Second), "bot removed")
 	
-	// Give it a moment to start
-	time.Sleep(100 * time.Millisecond)
-	
-	if !bot.IsProcessing() {
-		t.Error("Expected bot to be processing")
+	if err != nil || interrupted != o || o.Status != order.PENDING || !bot.IsIdle() {
+		t.Error("Expected order back to PENDING and bot idle")
 	}
 	
-	// Wait for completion
-	time.Sleep(11 * time.Second)
+	history := o.History()
+	if last := history[len(history)-1]; last.BotID != 1 || last.Reason != "bot removed" {
+		t.Errorf("Expected the interruption to be recorded, got %+v", last)
+	}
 	
-	if bot.IsProcessing() {
-		t.Error("Expected bot to be idle after completion")
+	// Busy time stops at retirement
+	if stats := bot.Stats(start.Add(time.Minute)); stats.BusyTime != 4*time.Second || stats.OrdersInterrupted != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
 	}
 }
 
-func TestBotStatusString(t *testing.T) {
-	if IDLE.String() != "IDLE" {
-		t.Errorf("Expected 'IDLE', got '%s'", IDLE.String())
+func TestAssignRejectsInvalidOrders(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	bot := NewBotAt(1, start)
+	first := order.NewOrderAt(1, order.Normal, start)
+	second := order.NewOrderAt(2, order.Normal, start)
+	
+	bot.Assign(first, start)
+	if err := bot.Assign(second, start); err == nil {
+		t.Error("Expected a busy bot to reject a second order")
+	}
+	if second.Status != order.PENDING {
+		t.Errorf("Expected rejected order to stay PENDING, got %v", second.Status)
 	}
 	
-	if PROCESSING.String() != "PROCESSING" {
-		t.Errorf("Expected 'PROCESSING', got '%s'", PROCESSING.String())
+	bot.Finish(start.Add(ProcessingTime))
+	if err := bot.Assign(first, start.Add(ProcessingTime)); !errors.Is(err, order.ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition assigning a completed order, got %v", err)
+	}
+	if _, err := bot.Finish(start.Add(ProcessingTime)); err == nil {
+		t.Error("Expected an idle bot to have nothing to finish")
+	}
+}
+
+func TestView(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	bot := NewBotAt(1, start)
+	o := order.NewOrderAt(7, order.Normal, start)
+	
+	bot.Assign(o, start)
+	view := bot.View()
+	bot.Finish(start.Add(ProcessingTime))
+	
+	// The snapshot does not follow later changes
+	if !view.IsProcessing() || view.OrderID != 7 || view.ID != 1 {
+		t.Errorf("Unexpected view %+v", view)
+	}
+	if after := bot.View(); !after.IsIdle() || after.OrderID != 0 {
+		t.Errorf("Expected idle view after finishing, got %+v", after)
 	}
 }
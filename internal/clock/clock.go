@@ -0,0 +1,160 @@
+package clock
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Timer is a pending callback scheduled with AfterFunc
+type Timer interface {
+	// Stop prevents the callback from running. Returns false if it already ran or was stopped.
+	Stop() bool
+}
+
+// Clock is the source of time for the controller
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Real returns a clock backed by the system time
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Virtual is a manually advanced clock. Callbacks run synchronously inside
+// Advance/AdvanceTo, in due-time order, so a run driven by a virtual clock is
+// fully deterministic.
+type Virtual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers timerHeap
+	seq    uint64
+}
+
+// NewVirtual creates a virtual clock starting at the given time
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+// Now returns the current virtual time
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// AfterFunc schedules f to run once the clock has advanced by d
+func (v *Virtual) AfterFunc(d time.Duration, f func()) Timer {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.seq++
+	t := &virtualTimer{clock: v, at: v.now.Add(d), seq: v.seq, fn: f, index: -1}
+	heap.Push(&v.timers, t)
+	return t
+}
+
+// Next returns the due time of the earliest scheduled callback
+func (v *Virtual) Next() (time.Time, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.timers) == 0 {
+		return time.Time{}, false
+	}
+	return v.timers[0].at, true
+}
+
+// Advance moves the clock forward by d, running every callback that falls due
+func (v *Virtual) Advance(d time.Duration) {
+	v.AdvanceTo(v.Now().Add(d))
+}
+
+// AdvanceTo moves the clock forward to t, running every callback due at or
+// before t. Callbacks see Now() equal to their due time and may schedule
+// further callbacks, which also run if they fall due before t.
+func (v *Virtual) AdvanceTo(t time.Time) {
+	for {
+		v.mu.Lock()
+		if len(v.timers) == 0 || v.timers[0].at.After(t) {
+			if t.After(v.now) {
+				v.now = t
+			}
+			v.mu.Unlock()
+			return
+		}
+		next := heap.Pop(&v.timers).(*virtualTimer)
+		if next.at.After(v.now) {
+			v.now = next.at
+		}
+		v.mu.Unlock()
+
+		// Run without holding the lock so the callback can use the clock
+		next.fn()
+	}
+}
+
+type virtualTimer struct {
+	clock *Virtual
+	at    time.Time
+	seq   uint64 // breaks ties so timers due together run in scheduling order
+	fn    func()
+	index int // position in the heap, -1 once removed
+}
+
+func (t *virtualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&t.clock.timers, t.index)
+	return true
+}
+
+// timerHeap orders timers by due time, then by scheduling order
+type timerHeap []*virtualTimer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x any) {
+	t := x.(*virtualTimer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
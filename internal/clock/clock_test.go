@@ -0,0 +1,81 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+func TestVirtualRunsCallbacksInOrder(t *testing.T) {
+	v := NewVirtual(epoch)
+	fired := make([]string, 0)
+	seen := make([]time.Time, 0)
+
+	record := func(name string) func() {
+		return func() {
+			fired = append(fired, name)
+			seen = append(seen, v.Now())
+		}
+	}
+
+	v.AfterFunc(3*time.Second, record("c"))
+	v.AfterFunc(1*time.Second, record("a"))
+	v.AfterFunc(1*time.Second, record("b"))
+	v.AfterFunc(10*time.Second, record("late"))
+
+	v.Advance(5 * time.Second)
+
+	if got := len(fired); got != 3 || fired[0] != "a" || fired[1] != "b" || fired[2] != "c" {
+		t.Fatalf("Expected a, b, c to fire in order, got %v", fired)
+	}
+	if !seen[2].Equal(epoch.Add(3 * time.Second)) {
+		t.Errorf("Expected callback to see its due time, got %v", seen[2])
+	}
+	if !v.Now().Equal(epoch.Add(5 * time.Second)) {
+		t.Errorf("Expected clock at +5s, got %v", v.Now())
+	}
+
+	next, ok := v.Next()
+	if !ok || !next.Equal(epoch.Add(10*time.Second)) {
+		t.Errorf("Expected next timer at +10s, got %v", next)
+	}
+}
+
+func TestVirtualStop(t *testing.T) {
+	v := NewVirtual(epoch)
+	fired := false
+	timer := v.AfterFunc(time.Second, func() { fired = true })
+
+	if !timer.Stop() {
+		t.Error("Expected Stop to succeed on a pending timer")
+	}
+	if timer.Stop() {
+		t.Error("Expected second Stop to return false")
+	}
+
+	v.Advance(time.Minute)
+	if fired {
+		t.Error("Expected stopped timer not to fire")
+	}
+	if _, ok := v.Next(); ok {
+		t.Error("Expected no timers left")
+	}
+}
+
+func TestVirtualChainedCallbacks(t *testing.T) {
+	v := NewVirtual(epoch)
+	count := 0
+
+	var tick func()
+	tick = func() {
+		count++
+		v.AfterFunc(10*time.Second, tick)
+	}
+	v.AfterFunc(10*time.Second, tick)
+
+	v.Advance(time.Minute)
+	if count != 6 {
+		t.Errorf("Expected 6 chained callbacks within a minute, got %d", count)
+	}
+}
@@ -0,0 +1,408 @@
+package config
+
+import (
+	"assignment/internal/archive"
+	"assignment/internal/autoscaler"
+	"assignment/internal/controller"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is prepended to the upper-cased key of every setting to form
+// its environment variable, e.g. ORDER_MANAGER_PROCESSING_TIME
+const EnvPrefix = "ORDER_MANAGER_"
+
+// Config holds every store-specific setting of the order manager
+type Config struct {
+	// Kitchen timings
+	ProcessingTime  time.Duration // processing_time: time a bot takes per order
+	MenuDelay       time.Duration // menu_delay: pause after each interactive menu choice
+	PickupTimeout   time.Duration // pickup_timeout: completed orders not collected within this are abandoned, 0 to disable
+	ReleaseLeadTime time.Duration // release_lead_time: scheduled orders join the queue this long before they are due
+
+	// Admission control for new orders
+	MaxPendingVIP    int  // max_pending_vip: VIP orders allowed to wait, 0 for no limit
+	MaxPendingNormal int  // max_pending_normal: Normal orders allowed to wait, 0 for no limit
+	MaxPending       int  // max_pending: orders of both types allowed to wait, 0 for no limit
+	Waitlist         bool // waitlist: waitlist new orders over the limits instead of rejecting them
+	MaxWaitlist      int  // max_waitlist: orders allowed on the waitlist before new ones are rejected, 0 for no limit
+	BusyThreshold    int  // busy_threshold: percentage of max_pending, or of both tier limits, at which the kitchen is busy, 0 to disable
+
+	// Verification
+	CheckInvariants bool // check_invariants: verify scheduling rules on every transition
+
+	// Archive of collected and abandoned orders
+	ArchiveMaxOrders int           // archive_max_orders: orders kept in the archive, 0 for no limit
+	ArchiveMaxAge    time.Duration // archive_max_age: how long archived orders are kept, 0 for no limit
+	ArchiveExport    string        // archive_export: JSON-lines file evicted orders are appended to, empty to disable
+
+	// Output
+	ResultPath      string // result: file the order log is written to
+	ResultFormat    string // result_format: order log format, text, json or csv
+	TimestampFormat string // timestamp_format: Go time layout used in log lines
+	LogFormat       string // log_format: stdout log format, text or json
+
+	// Serving
+	Bots        int    // bots: number of bots to start with
+	MetricsAddr string // metrics_addr: address of the /metrics endpoint, empty to disable
+	Menu        bool   // menu: read menu choices from stdin
+	Autoscale   bool   // autoscale: add and remove bots automatically
+	MinBots     int    // min_bots: autoscaler lower bound
+	MaxBots     int    // max_bots: autoscaler upper bound
+	RosterPath  string // roster: JSON shift schedule
+}
+
+// Default returns the built-in configuration
+func Default() Config {
+	kitchen := controller.DefaultConfig()
+	scaling := autoscaler.DefaultConfig()
+	return Config{
+		ProcessingTime:   kitchen.ProcessingTime,
+		MenuDelay:        200 * time.Millisecond,
+		PickupTimeout:    kitchen.PickupTimeout,
+		ArchiveMaxOrders: kitchen.Archive.MaxOrders,
+		ArchiveMaxAge:    kitchen.Archive.MaxAge,
+		ResultPath:       "scripts/result.txt",
+		ResultFormat:     "text",
+		TimestampFormat:  kitchen.TimestampFormat,
+		LogFormat:        "text",
+		Menu:             true,
+		MinBots:          scaling.MinBots,
+		MaxBots:          scaling.MaxBots,
+	}
+}
+
+// setters maps each setting key to the function applying a raw value
+var setters = map[string]func(c *Config, v string) error{
+	"processing_time":    durationSetter(func(c *Config) *time.Duration { return &c.ProcessingTime }),
+	"menu_delay":         durationSetter(func(c *Config) *time.Duration { return &c.MenuDelay }),
+	"pickup_timeout":     durationSetter(func(c *Config) *time.Duration { return &c.PickupTimeout }),
+	"release_lead_time":  durationSetter(func(c *Config) *time.Duration { return &c.ReleaseLeadTime }),
+	"max_pending_vip":    intSetter(func(c *Config) *int { return &c.MaxPendingVIP }),
+	"max_pending_normal": intSetter(func(c *Config) *int { return &c.MaxPendingNormal }),
+	"max_pending":        intSetter(func(c *Config) *int { return &c.MaxPending }),
+	"waitlist":           boolSetter(func(c *Config) *bool { return &c.Waitlist }),
+	"max_waitlist":       intSetter(func(c *Config) *int { return &c.MaxWaitlist }),
+	"busy_threshold":     intSetter(func(c *Config) *int { return &c.BusyThreshold }),
+	"check_invariants":   boolSetter(func(c *Config) *bool { return &c.CheckInvariants }),
+	"archive_max_orders": intSetter(func(c *Config) *int { return &c.ArchiveMaxOrders }),
+	"archive_max_age":    durationSetter(func(c *Config) *time.Duration { return &c.ArchiveMaxAge }),
+	"archive_export":     stringSetter(func(c *Config) *string { return &c.ArchiveExport }),
+	"result":             stringSetter(func(c *Config) *string { return &c.ResultPath }),
+	"result_format":      stringSetter(func(c *Config) *string { return &c.ResultFormat }),
+	"timestamp_format":   stringSetter(func(c *Config) *string { return &c.TimestampFormat }),
+	"log_format":         stringSetter(func(c *Config) *string { return &c.LogFormat }),
+	"bots":               intSetter(func(c *Config) *int { return &c.Bots }),
+	"metrics_addr":       stringSetter(func(c *Config) *string { return &c.MetricsAddr }),
+	"menu":               boolSetter(func(c *Config) *bool { return &c.Menu }),
+	"autoscale":          boolSetter(func(c *Config) *bool { return &c.Autoscale }),
+	"min_bots":           intSetter(func(c *Config) *int { return &c.MinBots }),
+	"max_bots":           intSetter(func(c *Config) *int { return &c.MaxBots }),
+	"roster":             stringSetter(func(c *Config) *string { return &c.RosterPath }),
+}
+
+func durationSetter(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func stringSetter(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func intSetter(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolSetter(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// Keys returns every setting key, sorted
+func Keys() []string {
+	keys := make([]string, 0, len(setters))
+	for k := range setters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set applies a single setting by key
+func (c *Config) Set(key, value string) error {
+	set, ok := setters[key]
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err := set(c, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// Load returns the defaults overridden by the given file (if path is not
+// empty) and then by environment variables. The file format is chosen by
+// extension: .json, .yaml/.yml or .toml. The YAML and TOML readers accept
+// the flat "key: value" / "key = value" subset that this configuration needs.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSON(data)
+	case ".yaml", ".yml":
+		values, err = parseFlat(data, ":")
+	case ".toml":
+		values, err = parseFlat(data, "=")
+	default:
+		err = fmt.Errorf("unsupported file extension %q, expected .json, .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	// Apply in key order so the first error reported is stable
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := c.Set(k, values[k]); err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// applyEnv overrides settings from ORDER_MANAGER_* environment variables
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for _, key := range Keys() {
+		name := EnvPrefix + strings.ToUpper(key)
+		if v, ok := lookup(name); ok {
+			if err := c.Set(key, v); err != nil {
+				return fmt.Errorf("config: %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseJSON reads a flat JSON object into raw string values
+func parseJSON(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case json.Number:
+			values[k] = v.String()
+		case bool:
+			values[k] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("%s: expected a string, number or boolean", k)
+		}
+	}
+	return values, nil
+}
+
+// parseFlat reads "key<sep>value" lines with # comments and optional quotes
+func parseFlat(data []byte, sep string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			return nil, fmt.Errorf("line %d: sections are not supported", line)
+		}
+
+		key, value, ok := strings.Cut(text, sep)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key %s value\"", line, sep)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("line %d: %s: missing value (nested settings are not supported)", line, key)
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// stripComment removes a trailing # comment outside of quotes
+func stripComment(s string) string {
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// Validate checks the configuration and reports every problem found
+func (c Config) Validate() error {
+	problems := make([]string, 0)
+	if c.ProcessingTime <= 0 {
+		problems = append(problems, fmt.Sprintf("processing_time must be positive, got %s", c.ProcessingTime))
+	}
+	if c.MenuDelay < 0 {
+		problems = append(problems, fmt.Sprintf("menu_delay must not be negative, got %s", c.MenuDelay))
+	}
+	if c.PickupTimeout < 0 {
+		problems = append(problems, fmt.Sprintf("pickup_timeout must not be negative, got %s", c.PickupTimeout))
+	}
+	if c.ReleaseLeadTime < 0 {
+		problems = append(problems, fmt.Sprintf("release_lead_time must not be negative, got %s", c.ReleaseLeadTime))
+	}
+	if c.MaxPendingVIP < 0 {
+		problems = append(problems, fmt.Sprintf("max_pending_vip must not be negative, got %d", c.MaxPendingVIP))
+	}
+	if c.MaxPendingNormal < 0 {
+		problems = append(problems, fmt.Sprintf("max_pending_normal must not be negative, got %d", c.MaxPendingNormal))
+	}
+	if c.MaxPending < 0 {
+		problems = append(problems, fmt.Sprintf("max_pending must not be negative, got %d", c.MaxPending))
+	}
+	if c.MaxWaitlist < 0 {
+		problems = append(problems, fmt.Sprintf("max_waitlist must not be negative, got %d", c.MaxWaitlist))
+	}
+	if c.MaxWaitlist > 0 && !c.Waitlist {
+		problems = append(problems, "max_waitlist has no effect unless waitlist is enabled")
+	}
+	if c.BusyThreshold < 0 || c.BusyThreshold > 100 {
+		problems = append(problems, fmt.Sprintf("busy_threshold must be between 0 and 100, got %d", c.BusyThreshold))
+	}
+	if c.BusyThreshold > 0 && c.MaxPending <= 0 && (c.MaxPendingVIP <= 0 || c.MaxPendingNormal <= 0) {
+		problems = append(problems, "busy_threshold needs max_pending, or both max_pending_vip and max_pending_normal, to measure the queue against")
+	}
+	if c.ArchiveMaxOrders < 0 {
+		problems = append(problems, fmt.Sprintf("archive_max_orders must not be negative, got %d", c.ArchiveMaxOrders))
+	}
+	if c.ArchiveMaxAge < 0 {
+		problems = append(problems, fmt.Sprintf("archive_max_age must not be negative, got %s", c.ArchiveMaxAge))
+	}
+	if c.ResultPath == "" {
+		problems = append(problems, "result must not be empty")
+	}
+	if c.ResultFormat != "text" && c.ResultFormat != "json" && c.ResultFormat != "csv" {
+		problems = append(problems, fmt.Sprintf("result_format must be text, json or csv, got %q", c.ResultFormat))
+	}
+	if c.TimestampFormat == "" {
+		problems = append(problems, "timestamp_format must not be empty")
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		problems = append(problems, fmt.Sprintf("log_format must be text or json, got %q", c.LogFormat))
+	}
+	if c.Bots < 0 {
+		problems = append(problems, fmt.Sprintf("bots must not be negative, got %d", c.Bots))
+	}
+	if c.Autoscale && c.RosterPath != "" {
+		problems = append(problems, "autoscale and roster cannot be used together")
+	}
+	if c.Autoscale && (c.MinBots < 0 || c.MaxBots < c.MinBots) {
+		problems = append(problems, fmt.Sprintf("autoscaler bounds must satisfy 0 <= min_bots <= max_bots, got %d and %d", c.MinBots, c.MaxBots))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Archive returns the archive retention policy
+func (c Config) Archive() archive.Config {
+	return archive.Config{MaxOrders: c.ArchiveMaxOrders, MaxAge: c.ArchiveMaxAge}
+}
+
+// Controller returns the controller settings
+func (c Config) Controller() controller.Config {
+	return controller.Config{
+		ProcessingTime:  c.ProcessingTime,
+		TimestampFormat: c.TimestampFormat,
+		CheckInvariants: c.CheckInvariants,
+		PickupTimeout:   c.PickupTimeout,
+		ReleaseLeadTime: c.ReleaseLeadTime,
+		QueueLimits: controller.QueueLimits{
+			MaxVIP:        c.MaxPendingVIP,
+			MaxNormal:     c.MaxPendingNormal,
+			MaxTotal:      c.MaxPending,
+			Waitlist:      c.Waitlist,
+			MaxWaitlist:   c.MaxWaitlist,
+			BusyThreshold: c.BusyThreshold,
+		},
+		Archive: c.Archive(),
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"assignment/internal/archive"
+	"assignment/internal/clock"
+	"assignment/internal/controller"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected defaults to be valid, got %v", err)
+	}
+	if cfg.ProcessingTime != 10*time.Second || cfg.TimestampFormat != "15:04:05" {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+}
+
+func TestDefaultKeepsQueuesBounded(t *testing.T) {
+	cfg := Default()
+	v := clock.NewVirtual(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	c := controller.NewController(func(string) {}, controller.WithConfig(cfg.Controller()),
+		controller.WithArchive(archive.NewStore(cfg.Archive(), nil)), controller.WithClock(v))
+	c.AddBot()
+
+	// A day of orders nobody collects, one every processing time
+	for i := 0; i < 8640; i++ {
+		if _, err := c.CreateNormalOrder(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		v.Advance(cfg.ProcessingTime)
+	}
+
+	// Uncollected orders are abandoned, leaving only those within the pickup timeout
+	limit := int(cfg.PickupTimeout/cfg.ProcessingTime) + 1
+	if n := len(c.GetCompleteOrders()); n > limit {
+		t.Errorf("Expected at most %d orders awaiting pickup, got %d", limit, n)
+	}
+	if n := len(c.GetNormalOrders()); n > limit+1 {
+		t.Errorf("Expected at most %d normal orders, got %d", limit+1, n)
+	}
+	if n := len(c.GetAbandonedOrders()); n == 0 {
+		t.Error("Expected uncollected orders to be abandoned")
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	files := map[string]string{
+		"store.json": `{"processing_time": "8s", "bots": 2, "menu": false, "result": "out/result.txt"}`,
+		"store.yaml": "# kitchen\nprocessing_time: 8s\nbots: 2\nmenu: false\nresult: \"out/result.txt\" # quoted\n",
+		"store.toml": "processing_time = \"8s\"\nbots = 2\nmenu = false\nresult = 'out/result.txt'\n",
+	}
+
+	for name, content := range files {
+		cfg, err := Load(writeFile(t, name, content))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if cfg.ProcessingTime != 8*time.Second || cfg.Bots != 2 || cfg.Menu || cfg.ResultPath != "out/result.txt" {
+			t.Errorf("%s: unexpected config %+v", name, cfg)
+		}
+		// Settings not in the file keep their defaults
+		if cfg.MenuDelay != 200*time.Millisecond {
+			t.Errorf("%s: expected default menu delay, got %v", name, cfg.MenuDelay)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	files := map[string]string{
+		"bad.json":   `{"processing_time": "soon"}`,
+		"typo.yaml":  "procesing_time: 8s\n",
+		"nested.yml": "autoscale:\n  min: 1\n",
+		"table.toml": "[kitchen]\nprocessing_time = \"8s\"\n",
+		"store.ini":  "processing_time=8s\n",
+	}
+
+	for name, content := range files {
+		if _, err := Load(writeFile(t, name, content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected error to name the file, got %v", name, err)
+		}
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	path := writeFile(t, "store.json", `{"processing_time": "8s", "bots": 2}`)
+	t.Setenv("ORDER_MANAGER_PROCESSING_TIME", "12s")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.ProcessingTime != 12*time.Second || cfg.Bots != 2 {
+		t.Errorf("Expected env to override the file, got %+v", cfg)
+	}
+
+	t.Setenv("ORDER_MANAGER_BOTS", "many")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "ORDER_MANAGER_BOTS") {
+		t.Errorf("Expected error naming the variable, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.ProcessingTime = 0
+	cfg.LogFormat = "xml"
+	cfg.ResultFormat = "parquet"
+	cfg.Autoscale = true
+	cfg.RosterPath = "roster.json"
+	cfg.ArchiveMaxOrders = -1
+	cfg.ReleaseLeadTime = -time.Minute
+	cfg.MaxPendingNormal = -1
+	cfg.MaxWaitlist = -1
+	cfg.BusyThreshold = 120
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"processing_time", "log_format", "result_format", "autoscale and roster", "archive_max_orders",
+		"release_lead_time", "max_pending_normal", "max_waitlist", "busy_threshold"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in error, got %v", want, err)
+		}
+	}
+}
+
+func TestBusyThresholdNeedsCapacity(t *testing.T) {
+	tests := []struct {
+		maxPending, maxVIP, maxNormal int
+		valid                         bool
+	}{
+		{0, 0, 0, false},
+		{0, 5, 0, false},
+		{0, 0, 5, false},
+		{0, 5, 5, true},
+		{10, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		cfg := Default()
+		cfg.BusyThreshold = 80
+		cfg.MaxPending, cfg.MaxPendingVIP, cfg.MaxPendingNormal = tt.maxPending, tt.maxVIP, tt.maxNormal
+		err := cfg.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt, err)
+		}
+		if !tt.valid && (err == nil || !strings.Contains(err.Error(), "busy_threshold needs max_pending")) {
+			t.Errorf("%+v: expected busy_threshold to be rejected, got %v", tt, err)
+		}
+	}
+}
+
+func TestMaxWaitlistNeedsWaitlist(t *testing.T) {
+	cfg := Default()
+	cfg.MaxPending = 10
+	cfg.MaxWaitlist = 5
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "max_waitlist has no effect") {
+		t.Errorf("Expected max_waitlist without waitlist to be rejected, got %v", err)
+	}
+	cfg.Waitlist = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
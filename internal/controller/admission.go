@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"assignment/internal/order"
+	"fmt"
+)
+
+// QueueLimits caps how many orders may wait in the queue, pending or held.
+// Limits apply to new orders only: scheduled orders being released, orders
+// returned by a removed bot, resumed and promoted orders always keep their place.
+type QueueLimits struct {
+	MaxVIP    int  // waiting VIP orders; 0 is unlimited
+	MaxNormal int  // waiting Normal orders; 0 is unlimited
+	MaxTotal  int  // waiting orders of both tiers; 0 is unlimited
+	Waitlist  bool // waitlist new orders over the limits instead of rejecting them
+
+	// MaxWaitlist caps the waitlist; once it is full new orders over the
+	// limits are rejected with ErrQueueFull. 0 is unlimited.
+	MaxWaitlist int
+
+	// BusyThreshold is the percentage of the queue's capacity at which
+	// EventKitchenBusy fires; 0 disables it. The capacity is MaxTotal, or
+	// MaxVIP plus MaxNormal when only the tier limits are set.
+	BusyThreshold int
+}
+
+// tierLimit returns the limit for one tier, 0 if unlimited
+func (l QueueLimits) tierLimit(typ order.OrderType) int {
+	if typ == order.VIP {
+		return l.MaxVIP
+	}
+	return l.MaxNormal
+}
+
+// capacity returns the most orders that may wait in the queue, 0 if unlimited
+func (l QueueLimits) capacity() int {
+	if l.MaxTotal > 0 {
+		return l.MaxTotal
+	}
+	if l.MaxVIP > 0 && l.MaxNormal > 0 {
+		return l.MaxVIP + l.MaxNormal
+	}
+	return 0
+}
+
+// GetWaitlistedOrders returns orders waiting for room in the queue in the
+// order they will be admitted: VIP first, oldest first within each tier
+func (c *Controller) GetWaitlistedOrders() []order.View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	waitlisted := make([]order.View, 0, len(c.waitlist))
+	waitlisted = appendViews(waitlisted, c.waitlist, isType(order.VIP))
+	waitlisted = appendViews(waitlisted, c.waitlist, isType(order.Normal))
+	return waitlisted
+}
+
+// IsKitchenBusy returns true while the waiting orders are at or above the
+// busy threshold
+func (c *Controller) IsKitchenBusy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.busy
+}
+
+// queueFull returns why a new order of the given type cannot join the queue
+// now, or "" if it can. Orders of a tier with waitlisted orders wait behind them.
+// Must be called with lock held
+func (c *Controller) queueFull(typ order.OrderType) string {
+	if reason := c.limitReached(typ); reason != "" {
+		return reason
+	}
+	waitlisted := 0
+	for _, o := range c.waitlist {
+		if o.Type == typ {
+			waitlisted++
+		}
+	}
+	if waitlisted > 0 {
+		return fmt.Sprintf("%d %s orders waitlisted ahead", waitlisted, typ)
+	}
+	return ""
+}
+
+// limitReached returns the queue limit an order of the given type would
+// exceed, or "" if there is room for it.
+// Must be called with lock held
+func (c *Controller) limitReached(typ order.OrderType) string {
+	vip, normal := countWaiting(c.vipOrders), countWaiting(c.normalOrders)
+	waiting := normal
+	if typ == order.VIP {
+		waiting = vip
+	}
+	if limit := c.limits.tierLimit(typ); limit > 0 && waiting >= limit {
+		return fmt.Sprintf("%d/%d %s orders waiting", waiting, limit, typ)
+	}
+	if limit := c.limits.MaxTotal; limit > 0 && vip+normal >= limit {
+		return fmt.Sprintf("%d/%d orders waiting", vip+normal, limit)
+	}
+	return ""
+}
+
+// turnAway handles a new order the queue has no room for: it is waitlisted
+// if the waitlist is enabled and has room, and rejected with ErrQueueFull
+// otherwise.
+// Must be called with lock held
+func (c *Controller) turnAway(typ order.OrderType, reason string) (order.View, error) {
+	now := c.clock.Now()
+	waitlistFull := c.limits.MaxWaitlist > 0 && len(c.waitlist) >= c.limits.MaxWaitlist
+	if c.limits.Waitlist && waitlistFull {
+		reason = fmt.Sprintf("%s, %d/%d orders waitlisted", reason, len(c.waitlist), c.limits.MaxWaitlist)
+	}
+	if !c.limits.Waitlist || waitlistFull {
+		c.logger(fmt.Sprintf("[%s] %s order rejected - queue full, %s", now.Format(c.timestampFormat), typ, reason))
+		c.emit(Event{Type: EventOrderRejected, Time: now, OrderType: typ, Detail: reason})
+		return order.View{}, fmt.Errorf("%w: %s", ErrQueueFull, reason)
+	}
+
+	c.orderCounter++
+	o := order.NewWaitlistedOrderAt(c.orderCounter, typ, now)
+	c.waitlist = append(c.waitlist, o)
+
+	c.logger(fmt.Sprintf("[%s] %s Order #%d waitlisted - %s - Status: %s", now.Format(c.timestampFormat), o.Type, o.ID, reason, o.Status))
+	c.emit(Event{Type: EventOrderWaitlisted, Time: now, OrderID: o.ID, OrderType: o.Type, Detail: reason})
+	return o.View(), nil
+}
+
+// admitWaitlisted moves waitlisted orders to the end of their tier's queue
+// while the limits allow, VIP first and oldest first within each tier, and
+// returns how many it admitted.
+// Must be called with lock held
+func (c *Controller) admitWaitlisted() int {
+	admitted := 0
+	for _, typ := range []order.OrderType{order.VIP, order.Normal} {
+		for c.limitReached(typ) == "" {
+			o := c.nextWaitlisted(typ)
+			if o == nil {
+				break
+			}
+			now := c.clock.Now()
+			if err := o.Transition(order.PENDING, now, 0, "admitted from the waitlist"); err != nil {
+				c.reportError(now, err)
+				return admitted
+			}
+			c.waitlist = remove(c.waitlist, o)
+			if o.IsVIP() {
+				c.vipOrders = append(c.vipOrders, o)
+			} else {
+				c.normalOrders = append(c.normalOrders, o)
+			}
+			admitted++
+
+			c.logger(fmt.Sprintf("[%s] Order #%d admitted - Status: %s", now.Format(c.timestampFormat), o.ID, o.Status))
+			c.emit(Event{Type: EventOrderAdmitted, Time: now, OrderID: o.ID, OrderType: o.Type})
+		}
+	}
+	return admitted
+}
+
+// nextWaitlisted returns the oldest waitlisted order of a tier, or nil.
+// Must be called with lock held
+func (c *Controller) nextWaitlisted(typ order.OrderType) *order.Order {
+	for _, o := range c.waitlist {
+		if o.Type == typ {
+			return o
+		}
+	}
+	return nil
+}
+
+// checkLoad emits EventKitchenBusy when the waiting orders reach the busy
+// threshold and EventKitchenAvailable when they drop back below it, so
+// kiosks can warn customers about longer waits.
+// Must be called with lock held
+func (c *Controller) checkLoad() {
+	capacity := c.limits.capacity()
+	if c.limits.BusyThreshold <= 0 || capacity == 0 {
+		return
+	}
+	waiting := countWaiting(c.vipOrders) + countWaiting(c.normalOrders)
+	busy := waiting*100 >= capacity*c.limits.BusyThreshold
+	if busy == c.busy {
+		return
+	}
+	c.busy = busy
+
+	now := c.clock.Now()
+	detail := fmt.Sprintf("%d/%d orders waiting", waiting, capacity)
+	eventType, state := EventKitchenAvailable, "available"
+	if busy {
+		eventType, state = EventKitchenBusy, "busy"
+	}
+	c.logger(fmt.Sprintf("[%s] Kitchen %s - %s", now.Format(c.timestampFormat), state, detail))
+	c.emit(Event{Type: eventType, Time: now, Detail: detail})
+}
+
+// countWaiting returns the orders in queue that no bot has taken yet
+func countWaiting(queue []*order.Order) int {
+	n := 0
+	for _, o := range queue {
+		if isWaiting(o) {
+			n++
+		}
+	}
+	return n
+}
@@ -1,345 +1,698 @@
 package controller
 
 import (
+	"assignment/internal/archive"
 	"assignment/internal/bot"
+	"assignment/internal/clock"
 	"assignment/internal/order"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
-// Controller manages orders and bots
+// Controller manages orders and bots.
+//
+// The controller owns every order and bot it creates: they are only changed
+// and read while holding mu, by the public methods and by processing timer
+// callbacks. Callers never see the live objects; every method returns
+// order.View and bot.View snapshots, which are safe to use from any goroutine.
 type Controller struct {
-	mu           sync.Mutex
-	vipOrders    []*order.Order  // Separate array for VIP orders
-	normalOrders []*order.Order  // Separate array for Normal orders
-	bots         []*bot.Bot
-	orderCounter int
-	botCounter   int
-	logger       func(string)
+	mu              sync.Mutex
+	vipOrders       []*order.Order // Separate array for VIP orders
+	normalOrders    []*order.Order // Separate array for Normal orders
+	ready           []*order.Order // Cooked orders awaiting pickup, out of the queues
+	scheduled       []*order.Order // Orders not yet released into a queue
+	waitlist        []*order.Order // Orders accepted while the queue was full, oldest first
+	bots            []*bot.Bot
+	retiredBots     []*bot.Bot          // Removed bots, kept for productivity reporting
+	archive         *archive.Store      // Collected and abandoned orders
+	timers          map[int]clock.Timer // Processing timers by bot ID
+	pickupTimers    map[int]clock.Timer // Pickup timeouts by order ID
+	releaseTimers   map[int]clock.Timer // Release times of scheduled orders by order ID
+	slaWatches      map[int]*slaWatch   // Orders with a promised time, by order ID
+	slaStats        SLAStats
+	orderCounter    int
+	botCounter      int
+	logger          func(string)
+	clock           clock.Clock
+	processingTime  time.Duration
+	pickupTimeout   time.Duration
+	releaseLeadTime time.Duration
+	limits          QueueLimits
+	timestampFormat string
+	checker         *InvariantChecker // nil unless invariant checks are enabled
+	busy            bool              // waiting orders are at or above the busy threshold
+	shutDown        bool
+	subMu           sync.RWMutex
+	subscribers     []func(Event)
+}
+
+// Config holds the store-specific controller settings
+type Config struct {
+	ProcessingTime  time.Duration  // time a bot takes per order
+	TimestampFormat string         // time layout used in log lines
+	CheckInvariants bool           // verify scheduling rules on every transition
+	PickupTimeout   time.Duration  // completed orders not collected within this are abandoned; 0 disables
+	ReleaseLeadTime time.Duration  // scheduled orders join the queue this long before they are due
+	QueueLimits     QueueLimits    // admission control for new orders; the zero value admits everything
+	Archive         archive.Config // retention of collected and abandoned orders
+}
+
+// DefaultConfig returns the settings from the README: 10 seconds per order
+// and HH:MM:SS timestamps. Uncollected orders are abandoned after 30 minutes
+// and the archive keeps the latest 10000, so memory stays bounded.
+func DefaultConfig() Config {
+	return Config{
+		ProcessingTime:  bot.ProcessingTime,
+		TimestampFormat: "15:04:05",
+		PickupTimeout:   30 * time.Minute,
+		Archive:         archive.Config{MaxOrders: 10000},
+	}
+}
+
+// Option customises a controller created by NewController
+type Option func(*Controller)
+
+// WithConfig applies store-specific settings
+func WithConfig(cfg Config) Option {
+	return func(c *Controller) {
+		c.processingTime = cfg.ProcessingTime
+		c.timestampFormat = cfg.TimestampFormat
+		c.pickupTimeout = cfg.PickupTimeout
+		c.releaseLeadTime = cfg.ReleaseLeadTime
+		c.limits = cfg.QueueLimits
+		c.archive = archive.NewStore(cfg.Archive, nil)
+		if cfg.CheckInvariants {
+			c.checker = NewInvariantChecker()
+		}
+	}
+}
+
+// WithInvariantChecks verifies the scheduling rules after every event and
+// emits an EventInvariantViolated event for each rule broken
+func WithInvariantChecks() Option {
+	return func(c *Controller) {
+		c.checker = NewInvariantChecker()
+	}
+}
+
+// WithClock makes the controller take time from the given clock.
+// A virtual clock makes the controller fully deterministic.
+func WithClock(clk clock.Clock) Option {
+	return func(c *Controller) {
+		c.clock = clk
+	}
+}
+
+// WithProcessingTime sets how long a bot takes to process one order
+func WithProcessingTime(d time.Duration) Option {
+	return func(c *Controller) {
+		c.processingTime = d
+	}
+}
+
+// WithPickupTimeout marks completed orders as ABANDONED when they are not
+// collected within d. Zero disables the timeout.
+func WithPickupTimeout(d time.Duration) Option {
+	return func(c *Controller) {
+		c.pickupTimeout = d
+	}
+}
+
+// WithReleaseLeadTime releases scheduled orders into the queue d before they are
+// due. By default they are released at their due time.
+func WithReleaseLeadTime(d time.Duration) Option {
+	return func(c *Controller) {
+		c.releaseLeadTime = d
+	}
+}
+
+// WithQueueLimits caps the orders waiting in the queue. New orders over the
+// limits are rejected with ErrQueueFull or, if limits.Waitlist is set, kept
+// on a waitlist until there is room.
+func WithQueueLimits(limits QueueLimits) Option {
+	return func(c *Controller) {
+		c.limits = limits
+	}
+}
+
+// WithArchive moves collected and abandoned orders into the given store,
+// which applies its own retention policy. By default the latest 10000 are kept.
+func WithArchive(store *archive.Store) Option {
+	return func(c *Controller) {
+		c.archive = store
+	}
 }
 
 // NewController creates a new controller
-func NewController(logger func(string)) *Controller {
-	return &Controller{
-		vipOrders:    make([]*order.Order, 0),
-		normalOrders: make([]*order.Order, 0),
-		bots:         make([]*bot.Bot, 0),
-		orderCounter: 0,
-		botCounter:   0,
-		logger:       logger,
+func NewController(logger func(string), opts ...Option) *Controller {
+	cfg := DefaultConfig()
+	c := &Controller{
+		vipOrders:       make([]*order.Order, 0),
+		normalOrders:    make([]*order.Order, 0),
+		bots:            make([]*bot.Bot, 0),
+		timers:          make(map[int]clock.Timer),
+		pickupTimers:    make(map[int]clock.Timer),
+		releaseTimers:   make(map[int]clock.Timer),
+		slaWatches:      make(map[int]*slaWatch),
+		archive:         archive.NewStore(cfg.Archive, nil),
+		orderCounter:    0,
+		botCounter:      0,
+		logger:          logger,
+		clock:           clock.Real(),
+		processingTime:  cfg.ProcessingTime,
+		timestampFormat: cfg.TimestampFormat,
+		pickupTimeout:   cfg.PickupTimeout,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// CreateNormalOrder creates a new normal order and adds it to the normal orders queue
-func (c *Controller) CreateNormalOrder() *order.Order {
+// CreateNormalOrder creates a new normal order and adds it to the normal orders queue.
+// When the queue is full it returns ErrQueueFull or waitlists the order.
+func (c *Controller) CreateNormalOrder() (order.View, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	if full := c.queueFull(order.Normal); full != "" {
+		return c.turnAway(order.Normal, full)
+	}
+	
 	c.orderCounter++
-	o := order.NewOrder(c.orderCounter, order.Normal)
+	o := order.NewOrderAt(c.orderCounter, order.Normal, c.clock.Now())
 	
 	// Normal orders go to the end of the normal orders array
 	c.normalOrders = append(c.normalOrders, o)
 	
-	timestamp := time.Now().Format("15:04:05")
+	timestamp := o.CreatedAt.Format(c.timestampFormat)
 	c.logger(fmt.Sprintf("[%s] Normal Order #%d created - Status: %s", timestamp, o.ID, o.Status))
+	c.emit(Event{Type: EventOrderCreated, Time: o.CreatedAt, OrderID: o.ID, OrderType: o.Type})
 	
 	// Try to assign to an idle bot
-	c.assignOrderToBot()
+	c.assignPendingOrders()
 	
-	return o
+	return o.View(), nil
 }
 
-// CreateVIPOrder creates a new VIP order and adds it to the VIP orders queue
-func (c *Controller) CreateVIPOrder() *order.Order {
+// CreateVIPOrder creates a new VIP order and adds it to the VIP orders queue.
+// When the queue is full it returns ErrQueueFull or waitlists the order.
+func (c *Controller) CreateVIPOrder() (order.View, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	if full := c.queueFull(order.VIP); full != "" {
+		return c.turnAway(order.VIP, full)
+	}
+	
 	c.orderCounter++
-	o := order.NewOrder(c.orderCounter, order.VIP)
+	o := order.NewOrderAt(c.orderCounter, order.VIP, c.clock.Now())
 	
 	// VIP orders go to the end of the VIP orders array (FIFO within VIP)
 	c.vipOrders = append(c.vipOrders, o)
 	
-	timestamp := time.Now().Format("15:04:05")
+	timestamp := o.CreatedAt.Format(c.timestampFormat)
 	c.logger(fmt.Sprintf("[%s] VIP Order #%d created - Status: %s", timestamp, o.ID, o.Status))
+	c.emit(Event{Type: EventOrderCreated, Time: o.CreatedAt, OrderID: o.ID, OrderType: o.Type})
 	
 	// Try to assign to an idle bot
-	c.assignOrderToBot()
+	c.assignPendingOrders()
 	
-	return o
+	return o.View(), nil
 }
 
-// AddBot creates a new bot and starts it processing orders
-func (c *Controller) AddBot() *bot.Bot {
+// AddBot creates a new bot and immediately starts it on any pending order
+func (c *Controller) AddBot() (bot.View, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
+	if c.shutDown {
+		return bot.View{}, ErrShutDown
+	}
+	
 	c.botCounter++
-	b := bot.NewBot(c.botCounter)
+	b := bot.NewBotAt(c.botCounter, c.clock.Now())
 	c.bots = append(c.bots, b)
 	
-	timestamp := time.Now().Format("15:04:05")
+	timestamp := b.CreatedAt.Format(c.timestampFormat)
 	c.logger(fmt.Sprintf("[%s] Bot #%d added", timestamp, b.ID))
+	c.emit(Event{Type: EventBotAdded, Time: b.CreatedAt, BotID: b.ID})
 	
 	// Start the bot processing orders
-	go c.processOrdersForBot(b)
+	c.assignPendingOrders()
 	
-	return b
+	return b.View(), nil
 }
 
-// RemoveBot removes the newest bot (last in the slice)
-func (c *Controller) RemoveBot() bool {
+// RemoveBot removes the newest bot (last in the slice) and returns a
+// snapshot of it as it was just before removal
+func (c *Controller) RemoveBot() (bot.View, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
+	if c.shutDown {
+		return bot.View{}, ErrShutDown
+	}
 	if len(c.bots) == 0 {
-		return false
+		return bot.View{}, ErrNoBots
 	}
 	
+	now := c.clock.Now()
+	timestamp := now.Format(c.timestampFormat)
+	
 	// Remove the last bot (newest)
 	b := c.bots[len(c.bots)-1]
-	c.bots = c.bots[:len(c.bots)-1]
+	removed := b.View()
 	
-	// Stop the bot if it's processing
+	// Stop the bot if it's processing. The order is returned first so that
+	// on failure the bot keeps both the order and its timer.
+	var requeued *order.Order
 	if b.IsProcessing() && b.CurrentOrder != nil {
-		b.Stop()
-		o := b.CurrentOrder
-		o.SetPending()
-		
-		// Re-insert the order back into the appropriate queue
-		c.insertOrderBack(o)
-		
-		timestamp := time.Now().Format("15:04:05")
-		c.logger(fmt.Sprintf("[%s] Bot #%d removed - Order #%d returned to PENDING", timestamp, b.ID, o.ID))
+		// The order keeps its place in its queue, so it is picked up again
+		// ahead of any order created after it
+		o, err := b.Interrupt(now, "bot removed")
+		if err != nil {
+			c.reportError(now, err)
+			return bot.View{}, err
+		}
+		c.timers[b.ID].Stop()
+		delete(c.timers, b.ID)
+		requeued = o
+	}
+	
+	c.bots = c.bots[:len(c.bots)-1]
+	b.Retire(now)
+	c.retiredBots = append(c.retiredBots, b)
+	
+	if requeued != nil {
+		c.logger(fmt.Sprintf("[%s] Bot #%d removed - Order #%d returned to PENDING", timestamp, b.ID, requeued.ID))
+		c.emit(Event{Type: EventOrderRequeued, Time: now, OrderID: requeued.ID, OrderType: requeued.Type, BotID: b.ID})
 	} else {
-		timestamp := time.Now().Format("15:04:05")
 		c.logger(fmt.Sprintf("[%s] Bot #%d removed", timestamp, b.ID))
 	}
+	c.emit(Event{Type: EventBotRemoved, Time: now, BotID: b.ID})
 	
 	// Try to assign any pending orders to remaining bots
-	c.assignOrderToBot()
+	c.assignPendingOrders()
 	
-	return true
+	return removed, nil
 }
 
-// takeNextOrderForBot finds and removes the next pending order for a bot.
-// Uses defer c.mu.Unlock() so the mutex is always released (even on panic or return).
-// Returns (order, true) if an order was taken, or (nil, false) if bot doesn't exist or no pending orders.
-func (c *Controller) takeNextOrderForBot(b *bot.Bot) (*order.Order, bool) {
+// Shutdown stops the controller: processing is abandoned, orders being
+// processed return to PENDING, and every later change returns ErrShutDown.
+// Read methods keep working on the final state.
+func (c *Controller) Shutdown() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-
-	// Check if bot still exists
-	botExists := false
-	for _, existingBot := range c.bots {
-		if existingBot.ID == b.ID {
-			botExists = true
-			break
+	
+	if c.shutDown {
+		return ErrShutDown
+	}
+	c.shutDown = true
+	
+	now := c.clock.Now()
+	timestamp := now.Format(c.timestampFormat)
+	for _, b := range c.bots {
+		if !b.IsProcessing() {
+			continue
+		}
+		// Interrupt first: if the order cannot return to PENDING, the bot
+		// keeps it and its timer still completes it
+		o, err := b.Interrupt(now, "controller shut down")
+		if err != nil {
+			c.reportError(now, err)
+			continue
 		}
+		c.timers[b.ID].Stop()
+		delete(c.timers, b.ID)
+		
+		c.logger(fmt.Sprintf("[%s] Bot #%d stopped - Order #%d returned to PENDING", timestamp, b.ID, o.ID))
+		c.emit(Event{Type: EventOrderRequeued, Time: now, OrderID: o.ID, OrderType: o.Type, BotID: b.ID})
 	}
-	if !botExists {
-		return nil, false
+	for id, t := range c.pickupTimers {
+		t.Stop()
+		delete(c.pickupTimers, id)
 	}
+	for id, t := range c.releaseTimers {
+		t.Stop()
+		delete(c.releaseTimers, id)
+	}
+	for id, w := range c.slaWatches {
+		w.stop()
+		delete(c.slaWatches, id)
+	}
+	c.logger(fmt.Sprintf("[%s] Controller shut down", timestamp))
+	return nil
+}
 
-	// Find next pending order (VIP first, then Normal)
-	var nextOrder *order.Order
-	var orderIndex int = -1
-	var isVIP bool = false
-
-	for i, o := range c.vipOrders {
+// nextPendingOrder returns the next order to process (VIP first, then Normal,
+// FIFO within each), or nil if nothing is pending.
+// Must be called with lock held
+func (c *Controller) nextPendingOrder() *order.Order {
+	for _, o := range c.vipOrders {
 		if o.Status == order.PENDING {
-			nextOrder = o
-			orderIndex = i
-			isVIP = true
-			break
+			return o
+		}
+	}
+	for _, o := range c.normalOrders {
+		if o.Status == order.PENDING {
+			return o
 		}
 	}
-	if nextOrder == nil {
-		for i, o := range c.normalOrders {
-			if o.Status == order.PENDING {
-				nextOrder = o
-				orderIndex = i
-				isVIP = false
+	return nil
+}
+
+// assignPendingOrders hands pending orders to idle bots, oldest bot first,
+// until either runs out, admitting waitlisted orders as room frees up, then
+// checks promised times and the kitchen load against the new schedule.
+// Must be called with lock held
+func (c *Controller) assignPendingOrders() {
+	for {
+		for _, b := range c.bots {
+			if !b.IsIdle() {
+				continue
+			}
+			o := c.nextPendingOrder()
+			if o == nil {
+				break
+			}
+			if err := c.startProcessing(b, o); err != nil {
+				c.reportError(c.clock.Now(), err)
 				break
 			}
 		}
+		// Admitted orders may be picked up by bots still idle
+		if c.admitWaitlisted() == 0 {
+			break
+		}
 	}
-	if nextOrder == nil {
-		return nil, true // bot exists but no orders
-	}
+	c.checkSLAs()
+	c.checkLoad()
+}
 
-	// Remove order from appropriate queue
-	if isVIP {
-		c.vipOrders = append(c.vipOrders[:orderIndex], c.vipOrders[orderIndex+1:]...)
-	} else {
-		c.normalOrders = append(c.normalOrders[:orderIndex], c.normalOrders[orderIndex+1:]...)
+// startProcessing assigns an order to a bot and schedules its completion.
+// Must be called with lock held
+func (c *Controller) startProcessing(b *bot.Bot, o *order.Order) error {
+	now := c.clock.Now()
+	if err := b.Assign(o, now); err != nil {
+		return err
 	}
-	return nextOrder, true
+	
+	c.logger(fmt.Sprintf("[%s] Bot #%d started processing Order #%d", now.Format(c.timestampFormat), b.ID, o.ID))
+	c.emit(Event{Type: EventOrderStarted, Time: now, OrderID: o.ID, OrderType: o.Type, BotID: b.ID,
+		Wait: o.WaitDuration()})
+	
+	c.timers[b.ID] = c.clock.AfterFunc(c.processingTime, func() {
+		c.finishProcessing(b, o)
+	})
+	return nil
 }
 
-// returnOrderToQueue inserts an order back into the appropriate queue.
-// Uses defer c.mu.Unlock() so the mutex is always released.
-func (c *Controller) returnOrderToQueue(o *order.Order) {
+// finishProcessing completes an order when its processing timer fires
+func (c *Controller) finishProcessing(b *bot.Bot, o *order.Order) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.insertOrderBack(o)
+	
+	// The bot may have been removed after the timer fired but before we got the lock
+	if b.CurrentOrder != o || !c.hasBot(b) {
+		return
+	}
+	delete(c.timers, b.ID)
+	
+	now := c.clock.Now()
+	if _, err := b.Finish(now); err != nil {
+		c.reportError(now, err)
+		return
+	}
+	
+	// Cooked orders leave their queue, so scheduling only ever scans
+	// orders still waiting or being processed
+	if o.IsVIP() {
+		c.vipOrders = remove(c.vipOrders, o)
+	} else {
+		c.normalOrders = remove(c.normalOrders, o)
+	}
+	c.ready = append(c.ready, o)
+	
+	c.logger(fmt.Sprintf("[%s] Order #%d completed by Bot #%d - Status: %s", now.Format(c.timestampFormat), o.ID, b.ID, o.Status))
+	c.emit(Event{Type: EventOrderCompleted, Time: now, OrderID: o.ID, OrderType: o.Type, BotID: b.ID,
+		Wait: o.WaitDuration(), Cook: o.CookDuration()})
+	c.settleSLA(o)
+	
+	// A bot that could not be stopped at shutdown still finishes its order,
+	// but nothing new is started
+	if c.shutDown {
+		return
+	}
+	c.startPickupTimeout(o)
+	
+	// Pick up the next pending order, if any
+	c.assignPendingOrders()
 }
 
-// processOrdersForBot continuously processes orders for a bot
-func (c *Controller) processOrdersForBot(b *bot.Bot) {
-	for {
-		// Check if bot should stop
-		select {
-		case <-b.ShouldStop():
-			return
-		default:
-		}
+// reportError logs a transition the controller attempted but the order or bot
+// rejected. It means the controller's own bookkeeping is wrong.
+// Must be called with lock held
+func (c *Controller) reportError(now time.Time, err error) {
+	c.logger(fmt.Sprintf("[%s] Error: %v", now.Format(c.timestampFormat), err))
+}
 
-		nextOrder, ok := c.takeNextOrderForBot(b)
-		if !ok {
-			return // bot was removed
-		}
-		if nextOrder == nil {
-			// No pending orders, bot becomes idle
-			select {
-			case <-b.ShouldStop():
-				return
-			case <-time.After(100 * time.Millisecond):
-				continue
-			}
+// hasBot returns true if the bot is still active.
+// Must be called with lock held
+func (c *Controller) hasBot(b *bot.Bot) bool {
+	for _, existing := range c.bots {
+		if existing == b {
+			return true
 		}
+	}
+	return false
+}
 
-		// Process the order (outside of lock - no defer needed here)
-		timestamp := time.Now().Format("15:04:05")
-		c.logger(fmt.Sprintf("[%s] Bot #%d started processing Order #%d", timestamp, b.ID, nextOrder.ID))
-
-		completed := b.StartProcessing(nextOrder)
+// remove returns queue without o
+func remove(queue []*order.Order, o *order.Order) []*order.Order {
+	i := indexOf(queue, o)
+	return append(queue[:i], queue[i+1:]...)
+}
 
-		if completed {
-			timestamp := time.Now().Format("15:04:05")
-			c.logger(fmt.Sprintf("[%s] Order #%d completed by Bot #%d - Status: %s", timestamp, nextOrder.ID, b.ID, nextOrder.Status))
+// indexOf returns the position of o in queue, -1 if it is not there
+func indexOf(queue []*order.Order, o *order.Order) int {
+	for i, queued := range queue {
+		if queued == o {
+			return i
 		}
-		// Return order to queue (completed for tracking, or cancelled back to pending)
-		c.returnOrderToQueue(nextOrder)
 	}
+	return -1
 }
 
-// assignOrderToBot tries to assign a pending order to an idle bot
-// Must be called with lock held
-func (c *Controller) assignOrderToBot() {
-	// Find idle bots
-	idleBots := make([]*bot.Bot, 0)
-	for _, b := range c.bots {
-		if b.IsIdle() {
-			idleBots = append(idleBots, b)
-		}
-	}
+// GetState returns a snapshot of all orders (VIP first) and active bots.
+// Within each type, cooked orders awaiting pickup come first, then the queue.
+func (c *Controller) GetState() ([]order.View, []bot.View) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	
-	// Count pending orders (VIP + Normal)
-	pendingCount := 0
-	for _, o := range c.vipOrders {
-		if o.Status == order.PENDING {
-			pendingCount++
-		}
+	// Combine VIP and Normal orders (VIP first)
+	allOrders := make([]order.View, 0, len(c.vipOrders)+len(c.normalOrders)+len(c.ready))
+	allOrders = c.appendTypeViews(allOrders, order.VIP)
+	allOrders = c.appendTypeViews(allOrders, order.Normal)
+	
+	bots := make([]bot.View, len(c.bots))
+	for i, b := range c.bots {
+		bots[i] = b.View()
 	}
-	for _, o := range c.normalOrders {
-		if o.Status == order.PENDING {
-			pendingCount++
-		}
+	
+	return allOrders, bots
+}
+
+// GetOrder returns a snapshot of the order with the given ID
+func (c *Controller) GetOrder(id int) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	o := c.findOrder(id)
+	if o == nil {
+		return order.View{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
 	}
+	return o.View(), nil
+}
+
+// GetOrderHistory returns every status change of the order with the given
+// ID, oldest first
+func (c *Controller) GetOrderHistory(id int) ([]order.Transition, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	
-	// The bot will pick up the order in its processing loop
-	// We just need to ensure the bot is running
+	o := c.findOrder(id)
+	if o == nil {
+		return nil, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+	return o.History(), nil
 }
 
-// insertOrderBack inserts an order back into the appropriate queue
+// findOrder returns the order with the given ID, live, scheduled,
+// waitlisted or archived, or nil.
 // Must be called with lock held
-func (c *Controller) insertOrderBack(o *order.Order) {
-	if o.IsVIP() {
-		// VIP orders go back to VIP queue
-		c.vipOrders = append(c.vipOrders, o)
-	} else {
-		// Normal orders go back to Normal queue
-		c.normalOrders = append(c.normalOrders, o)
+func (c *Controller) findOrder(id int) *order.Order {
+	for _, queue := range [][]*order.Order{c.vipOrders, c.normalOrders, c.ready, c.scheduled, c.waitlist} {
+		for _, o := range queue {
+			if o.ID == id {
+				return o
+			}
+		}
 	}
+	return c.archive.Get(id)
 }
 
-// GetState returns the current state of the system
-func (c *Controller) GetState() ([]*order.Order, []*bot.Bot) {
+// GetPendingOrders returns all pending orders, VIP first
+func (c *Controller) GetPendingOrders() []order.View {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
-	// Combine VIP and Normal orders (VIP first)
-	allOrders := make([]*order.Order, 0, len(c.vipOrders)+len(c.normalOrders))
-	allOrders = append(allOrders, c.vipOrders...)
-	allOrders = append(allOrders, c.normalOrders...)
-	
-	// Return copies to avoid race conditions
-	ordersCopy := make([]*order.Order, len(allOrders))
-	copy(ordersCopy, allOrders)
+	pending := make([]order.View, 0)
+	pending = appendViews(pending, c.vipOrders, isStatus(order.PENDING))
+	pending = appendViews(pending, c.normalOrders, isStatus(order.PENDING))
+	return pending
+}
+
+// GetCompleteOrders returns completed orders awaiting pickup, VIP first.
+// Collected and abandoned orders are in the archive instead.
+func (c *Controller) GetCompleteOrders() []order.View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	
-	botsCopy := make([]*bot.Bot, len(c.bots))
-	copy(botsCopy, c.bots)
+	complete := make([]order.View, 0, len(c.ready))
+	complete = appendViews(complete, c.ready, isType(order.VIP))
+	complete = appendViews(complete, c.ready, isType(order.Normal))
+	return complete
+}
+
+// GetVIPOrders returns all VIP orders not yet collected or abandoned
+func (c *Controller) GetVIPOrders() []order.View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	
-	return ordersCopy, botsCopy
+	return c.appendTypeViews(make([]order.View, 0, len(c.vipOrders)), order.VIP)
 }
 
-// GetPendingOrders returns all pending orders
-func (c *Controller) GetPendingOrders() []*order.Order {
+// GetNormalOrders returns all Normal orders not yet collected or abandoned
+func (c *Controller) GetNormalOrders() []order.View {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
-	pending := make([]*order.Order, 0)
-	for _, o := range c.vipOrders {
-		if o.Status == order.PENDING {
-			pending = append(pending, o)
-		}
+	return c.appendTypeViews(make([]order.View, 0, len(c.normalOrders)), order.Normal)
+}
+
+// appendTypeViews appends snapshots of the cooked orders awaiting pickup and
+// then the queued orders of one type.
+// Must be called with lock held
+func (c *Controller) appendTypeViews(views []order.View, typ order.OrderType) []order.View {
+	views = appendViews(views, c.ready, isType(typ))
+	if typ == order.VIP {
+		return appendViews(views, c.vipOrders, nil)
 	}
-	for _, o := range c.normalOrders {
-		if o.Status == order.PENDING {
-			pending = append(pending, o)
+	return appendViews(views, c.normalOrders, nil)
+}
+
+// appendViews appends snapshots of the orders accepted by keep (all if nil).
+// Must be called with lock held
+func appendViews(views []order.View, orders []*order.Order, keep func(*order.Order) bool) []order.View {
+	for _, o := range orders {
+		if keep == nil || keep(o) {
+			views = append(views, o.View())
 		}
 	}
-	return pending
+	return views
+}
+
+func isStatus(status order.OrderStatus) func(*order.Order) bool {
+	return func(o *order.Order) bool { return o.Status == status }
 }
 
-// GetCompleteOrders returns all completed orders
-func (c *Controller) GetCompleteOrders() []*order.Order {
+func isType(typ order.OrderType) func(*order.Order) bool {
+	return func(o *order.Order) bool { return o.Type == typ }
+}
+
+// BotCount returns the number of bots currently in the system
+func (c *Controller) BotCount() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	complete := make([]*order.Order, 0)
+
+	return len(c.bots)
+}
+
+// NewestBotIdle returns true if the bot RemoveBot would remove is idle,
+// false if it is processing an order or there are no bots
+func (c *Controller) NewestBotIdle() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.bots) > 0 && !c.bots[len(c.bots)-1].IsProcessing()
+}
+
+// PendingCount returns the number of orders waiting to be picked up by a bot
+func (c *Controller) PendingCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
 	for _, o := range c.vipOrders {
-		if o.Status == order.COMPLETE {
-			complete = append(complete, o)
+		if o.Status == order.PENDING {
+			count++
 		}
 	}
 	for _, o := range c.normalOrders {
-		if o.Status == order.COMPLETE {
-			complete = append(complete, o)
+		if o.Status == order.PENDING {
+			count++
 		}
 	}
-	return complete
+	return count
 }
 
-// GetVIPOrders returns all VIP orders
-func (c *Controller) GetVIPOrders() []*order.Order {
+// OldestPendingWait returns how long the oldest pending order has been waiting.
+// Returns 0 when there are no pending orders.
+func (c *Controller) OldestPendingWait() time.Duration {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	vipCopy := make([]*order.Order, len(c.vipOrders))
-	copy(vipCopy, c.vipOrders)
-	return vipCopy
+
+	var oldest time.Time
+	for _, queue := range [][]*order.Order{c.vipOrders, c.normalOrders} {
+		for _, o := range queue {
+			if o.Status != order.PENDING {
+				continue
+			}
+			if oldest.IsZero() || o.QueuedAt().Before(oldest) {
+				oldest = o.QueuedAt()
+			}
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return c.clock.Now().Sub(oldest)
 }
 
-// GetNormalOrders returns all Normal orders
-func (c *Controller) GetNormalOrders() []*order.Order {
+// GetBotStats returns productivity statistics for every bot ever added,
+// active bots first followed by removed bots, each in creation order
+func (c *Controller) GetBotStats() []bot.Stats {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	normalCopy := make([]*order.Order, len(c.normalOrders))
-	copy(normalCopy, c.normalOrders)
-	return normalCopy
+
+	now := c.clock.Now()
+	result := make([]bot.Stats, 0, len(c.bots)+len(c.retiredBots))
+	for _, b := range c.bots {
+		result = append(result, b.Stats(now))
+	}
+	retired := make([]bot.Stats, 0, len(c.retiredBots))
+	for _, b := range c.retiredBots {
+		retired = append(retired, b.Stats(now))
+	}
+	sort.Slice(retired, func(i, j int) bool { return retired[i].ID < retired[j].ID })
+	return append(result, retired...)
 }
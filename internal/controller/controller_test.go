@@ -1,7 +1,12 @@
 package controller
 
 import (
+	"assignment/internal/archive"
+	"assignment/internal/bot"
+	"assignment/internal/clock"
 	"assignment/internal/order"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -14,7 +19,7 @@ func TestCreateNormalOrder(t *testing.T) {
 	}
 	
 	c := NewController(logger)
-	o := c.CreateNormalOrder()
+	o, _ := c.CreateNormalOrder()
 	
 	if o.ID != 1 {
 		t.Errorf("Expected order ID 1, got %d", o.ID)
@@ -36,7 +41,7 @@ func TestCreateVIPOrder(t *testing.T) {
 	}
 	
 	c := NewController(logger)
-	o := c.CreateVIPOrder()
+	o, _ := c.CreateVIPOrder()
 	
 	if o.ID != 1 {
 		t.Errorf("Expected order ID 1, got %d", o.ID)
@@ -55,13 +60,13 @@ func TestVIPPriority(t *testing.T) {
 	c.CreateNormalOrder()
 	
 	// Create VIP order - should be at front
-	vipOrder := c.CreateVIPOrder()
+	vipOrder, _ := c.CreateVIPOrder()
 	
 	// Create another normal order
 	c.CreateNormalOrder()
 	
 	// Create another VIP order - should be after first VIP but before normal
-	vipOrder2 := c.CreateVIPOrder()
+	vipOrder2, _ := c.CreateVIPOrder()
 	
 	pending := c.GetPendingOrders()
 	
@@ -90,7 +95,7 @@ func TestAddBot(t *testing.T) {
 	}
 	
 	c := NewController(logger)
-	b := c.AddBot()
+	b, _ := c.AddBot()
 	
 	if b.ID != 1 {
 		t.Errorf("Expected bot ID 1, got %d", b.ID)
@@ -116,15 +121,35 @@ func TestRemoveBot(t *testing.T) {
 	c.AddBot()
 	
 	// Remove it
-	removed := c.RemoveBot()
-	if !removed {
-		t.Error("Expected bot to be removed")
+	removed, err := c.RemoveBot()
+	if err != nil || removed.ID != 1 {
+		t.Errorf("Expected bot #1 to be removed, got %+v, %v", removed, err)
 	}
 	
 	// Try to remove again (should fail)
-	removed = c.RemoveBot()
-	if removed {
-		t.Error("Expected removal to fail when no bots exist")
+	if _, err := c.RemoveBot(); !errors.Is(err, ErrNoBots) {
+		t.Errorf("Expected ErrNoBots when no bots exist, got %v", err)
+	}
+}
+
+func TestNewestBotIdle(t *testing.T) {
+	c := NewController(func(string) {})
+	if c.NewestBotIdle() {
+		t.Error("Expected false without bots")
+	}
+
+	c.CreateNormalOrder()
+	c.AddBot()
+	time.Sleep(300 * time.Millisecond)
+	c.AddBot()
+	if !c.NewestBotIdle() {
+		t.Error("Expected the newest bot idle while the first one cooks")
+	}
+
+	c.CreateNormalOrder()
+	time.Sleep(300 * time.Millisecond)
+	if c.NewestBotIdle() {
+		t.Error("Expected the newest bot busy once it takes an order")
 	}
 }
 
@@ -137,7 +162,7 @@ func TestRemoveBotWhileProcessing(t *testing.T) {
 	c := NewController(logger)
 	
 	// Create an order
-	o := c.CreateNormalOrder()
+	o, _ := c.CreateNormalOrder()
 	
 	// Add a bot (will start processing)
 	c.AddBot()
@@ -175,7 +200,7 @@ func TestOrderProcessing(t *testing.T) {
 	c := NewController(logger)
 	
 	// Create an order
-	o := c.CreateNormalOrder()
+	o, _ := c.CreateNormalOrder()
 	
 	// Add a bot
 	c.AddBot()
@@ -211,3 +236,1074 @@ func TestOrderProcessing(t *testing.T) {
 		t.Error("Expected order to be completed")
 	}
 }
+
+func TestBotStatsRetainedAfterRemoval(t *testing.T) {
+	c := NewController(func(string) {})
+
+	c.AddBot()
+	c.AddBot()
+	c.RemoveBot()
+
+	stats := c.GetBotStats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected stats for 2 bots, got %d", len(stats))
+	}
+
+	if stats[0].ID != 1 || !stats[0].RemovedAt.IsZero() {
+		t.Errorf("Expected active Bot #1 first, got %+v", stats[0])
+	}
+
+	if stats[1].ID != 2 || stats[1].RemovedAt.IsZero() {
+		t.Errorf("Expected removed Bot #2 to be retained, got %+v", stats[1])
+	}
+}
+
+var epoch = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func TestProcessingWithVirtualClock(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	normal := createOrder(t, c, order.Normal)
+	vip := createOrder(t, c, order.VIP)
+	c.AddBot()
+
+	// The bot picks up the VIP order immediately
+	if s, n := orderStatus(t, c, vip), orderStatus(t, c, normal); s != order.PROCESSING || n != order.PENDING {
+		t.Fatalf("Expected VIP order processing first, got VIP %v, Normal %v", s, n)
+	}
+
+	v.Advance(10 * time.Second)
+	if o, _ := c.GetOrder(vip); o.Status != order.COMPLETE || !o.CompletedAt.Equal(epoch.Add(10*time.Second)) {
+		t.Errorf("Expected VIP order complete at +10s, got %v at %v", o.Status, o.CompletedAt)
+	}
+	if s := orderStatus(t, c, normal); s != order.PROCESSING {
+		t.Errorf("Expected bot to move on to the Normal order, got %v", s)
+	}
+
+	v.Advance(10 * time.Second)
+	if o, _ := c.GetOrder(normal); o.Status != order.COMPLETE || o.WaitDuration() != 10*time.Second {
+		t.Errorf("Expected Normal order complete after a 10s wait, got %v (wait %v)", o.Status, o.WaitDuration())
+	}
+}
+
+func TestRemovedBotOrderKeepsPlace(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	first := createOrder(t, c, order.Normal)
+	second := createOrder(t, c, order.Normal)
+	c.AddBot()
+	c.AddBot()
+
+	v.Advance(5 * time.Second)
+	c.RemoveBot()
+	if s := orderStatus(t, c, second); s != order.PENDING {
+		t.Fatalf("Expected order #%d back to PENDING, got %v", second, s)
+	}
+
+	third := createOrder(t, c, order.Normal)
+	v.Advance(5 * time.Second)
+
+	if s := orderStatus(t, c, first); s != order.COMPLETE {
+		t.Errorf("Expected order #%d complete, got %v", first, s)
+	}
+	// The interrupted order is picked up before the newer one
+	if s2, s3 := orderStatus(t, c, second), orderStatus(t, c, third); s2 != order.PROCESSING || s3 != order.PENDING {
+		t.Errorf("Expected order #%d processing before #%d, got %v and %v", second, third, s2, s3)
+	}
+
+	if n := len(c.GetNormalOrders()); n != 3 {
+		t.Errorf("Expected 3 normal orders without duplicates, got %d", n)
+	}
+}
+
+func TestRemoveBotKeepsOrderOnFailedInterrupt(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := NewController(func(string) {}, WithClock(v))
+
+	id := createOrder(t, c, order.Normal)
+	c.AddBot()
+
+	// Corrupt the order so the bot cannot return it to PENDING
+	o := c.bots[0].CurrentOrder
+	o.Status = order.COMPLETE
+	if _, err := c.RemoveBot(); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+	}
+	if n := c.BotCount(); n != 1 {
+		t.Errorf("Expected the bot to be kept, got %d bots", n)
+	}
+	if len(c.retiredBots) != 0 {
+		t.Errorf("Expected no retired bots, got %d", len(c.retiredBots))
+	}
+
+	// The bot still owns the order and its timer still completes it
+	o.Status = order.PROCESSING
+	v.Advance(10 * time.Second)
+	if s := orderStatus(t, c, id); s != order.COMPLETE {
+		t.Errorf("Expected order #%d complete, got %v", id, s)
+	}
+}
+
+func TestWithProcessingTime(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := NewController(func(string) {}, WithClock(v), WithProcessingTime(3*time.Second))
+
+	o, _ := c.CreateNormalOrder()
+	c.AddBot()
+	v.Advance(3 * time.Second)
+
+	if s := orderStatus(t, c, o.ID); s != order.COMPLETE {
+		t.Errorf("Expected order complete after 3s, got %v", s)
+	}
+}
+
+func TestCookedOrdersLeaveQueues(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := NewController(func(string) {}, WithClock(v))
+	c.AddBot()
+	for i := 0; i < 50; i++ {
+		c.CreateNormalOrder()
+		c.CreateVIPOrder()
+	}
+	v.Advance(1000 * time.Second)
+
+	// Scheduling scans the queues on every change, so they must only hold
+	// orders still to cook
+	if n := len(c.vipOrders) + len(c.normalOrders); n != 0 {
+		t.Errorf("Expected empty queues once every order is cooked, got %d orders", n)
+	}
+	if n := len(c.GetCompleteOrders()); n != 100 {
+		t.Errorf("Expected 100 orders awaiting pickup, got %d", n)
+	}
+	orders, _ := c.GetState()
+	if len(orders) != 100 || orders[0].Type != order.VIP {
+		t.Errorf("Expected all 100 orders in the state, VIP first, got %d", len(orders))
+	}
+}
+
+func TestWithConfigTimestampFormat(t *testing.T) {
+	var logs []string
+	cfg := Config{ProcessingTime: time.Second, TimestampFormat: time.RFC3339}
+	c := NewController(func(msg string) { logs = append(logs, msg) }, WithClock(clock.NewVirtual(epoch)), WithConfig(cfg))
+
+	c.CreateNormalOrder()
+
+	want := "[2024-03-01T12:00:00Z] Normal Order #1 created - Status: PENDING"
+	if len(logs) != 1 || logs[0] != want {
+		t.Errorf("Expected %q, got %v", want, logs)
+	}
+}
+
+// newCheckedController creates a controller with invariant checks that fails
+// the test on every violation
+func newCheckedController(t *testing.T, opts ...Option) *Controller {
+	t.Helper()
+	c := NewController(func(string) {}, append(opts, WithInvariantChecks())...)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventInvariantViolated {
+			t.Errorf("Invariant violated: %s", e.Detail)
+		}
+	})
+	return c
+}
+
+func TestInvariantChecksUnderChurn(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	for i := 0; i < 20; i++ {
+		if i%3 == 0 {
+			c.CreateVIPOrder()
+		} else {
+			c.CreateNormalOrder()
+		}
+		if i%4 == 0 {
+			c.AddBot()
+		}
+		if i%7 == 6 {
+			c.RemoveBot()
+		}
+		v.Advance(3 * time.Second)
+	}
+	v.Advance(5 * time.Minute)
+
+	if n := len(c.GetCompleteOrders()); n != 20 {
+		t.Errorf("Expected all 20 orders complete, got %d", n)
+	}
+}
+
+func TestInvariantViolationEmitted(t *testing.T) {
+	var logs []string
+	c := NewController(func(msg string) { logs = append(logs, msg) }, WithClock(clock.NewVirtual(epoch)), WithInvariantChecks())
+	violations := make([]Event, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventInvariantViolated {
+			violations = append(violations, e)
+		}
+	})
+
+	normal, _ := c.CreateNormalOrder()
+	c.CreateVIPOrder()
+
+	// Force the Normal order past the pending VIP one
+	c.mu.Lock()
+	b := bot.NewBotAt(99, epoch)
+	c.bots = append(c.bots, b)
+	c.startProcessing(b, c.normalOrders[0])
+	c.mu.Unlock()
+
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), violations)
+	}
+	want := "Normal Order #1 started while VIP Order #2 was pending"
+	if violations[0].Detail != want || violations[0].OrderID != normal.ID || violations[0].BotID != 99 {
+		t.Errorf("Unexpected violation %+v", violations[0])
+	}
+	if last := logs[len(logs)-1]; !strings.Contains(last, "Invariant violated: "+want) {
+		t.Errorf("Expected violation to be logged, got %q", last)
+	}
+}
+
+// orderStatus returns the current status of an order that must exist
+func orderStatus(t *testing.T, c *Controller, id int) order.OrderStatus {
+	t.Helper()
+	o, err := c.GetOrder(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o.Status
+}
+
+// createOrder creates an order of the given type and returns its ID
+func createOrder(t *testing.T, c *Controller, orderType order.OrderType) int {
+	t.Helper()
+	create := c.CreateNormalOrder
+	if orderType == order.VIP {
+		create = c.CreateVIPOrder
+	}
+	o, err := create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o.ID
+}
+
+func TestViewsAreSnapshots(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := NewController(func(string) {}, WithClock(v))
+
+	created, _ := c.CreateNormalOrder()
+	c.AddBot()
+	_, bots := c.GetState()
+	v.Advance(10 * time.Second)
+
+	// Earlier snapshots keep the state they were taken in
+	if created.Status != order.PENDING {
+		t.Errorf("Expected creation snapshot to stay PENDING, got %v", created.Status)
+	}
+	if !bots[0].IsProcessing() || bots[0].OrderID != created.ID {
+		t.Errorf("Expected bot snapshot processing order #%d, got %+v", created.ID, bots[0])
+	}
+	if s := orderStatus(t, c, created.ID); s != order.COMPLETE {
+		t.Errorf("Expected order complete, got %v", s)
+	}
+	if _, err := c.GetOrder(42); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound for an unknown order, got %v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	logs := make([]string, 0)
+	c := NewController(func(s string) { logs = append(logs, s) }, WithClock(v))
+
+	id := createOrder(t, c, order.Normal)
+	c.AddBot()
+	if err := c.Shutdown(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The interrupted order is returned and its timer no longer fires
+	v.Advance(time.Minute)
+	if s := orderStatus(t, c, id); s != order.PENDING {
+		t.Errorf("Expected order back to PENDING after shutdown, got %v", s)
+	}
+	if last := logs[len(logs)-1]; !strings.Contains(last, "Controller shut down") {
+		t.Errorf("Expected shutdown to be logged, got %q", last)
+	}
+
+	// Every change is rejected afterwards
+	if _, err := c.CreateVIPOrder(); !errors.Is(err, ErrShutDown) {
+		t.Errorf("Expected ErrShutDown creating an order, got %v", err)
+	}
+	if _, err := c.AddBot(); !errors.Is(err, ErrShutDown) {
+		t.Errorf("Expected ErrShutDown adding a bot, got %v", err)
+	}
+	if _, err := c.RemoveBot(); !errors.Is(err, ErrShutDown) {
+		t.Errorf("Expected ErrShutDown removing a bot, got %v", err)
+	}
+	if err := c.Shutdown(); !errors.Is(err, ErrShutDown) {
+		t.Errorf("Expected second shutdown to fail, got %v", err)
+	}
+}
+
+func TestShutdownKeepsOrderOnFailedInterrupt(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := NewController(func(string) {}, WithClock(v))
+
+	first := createOrder(t, c, order.Normal)
+	second := createOrder(t, c, order.Normal)
+	c.AddBot()
+
+	// Corrupt the order so the bot cannot return it to PENDING
+	o := c.bots[0].CurrentOrder
+	o.Status = order.COMPLETE
+	if err := c.Shutdown(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The bot's timer still completes its order, but nothing new starts
+	o.Status = order.PROCESSING
+	v.Advance(time.Minute)
+	if s := orderStatus(t, c, first); s != order.COMPLETE {
+		t.Errorf("Expected order #%d complete, got %v", first, s)
+	}
+	if s := orderStatus(t, c, second); s != order.PENDING {
+		t.Errorf("Expected order #%d still PENDING after shutdown, got %v", second, s)
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	id := createOrder(t, c, order.Normal)
+	c.AddBot()
+	v.Advance(4 * time.Second)
+	c.RemoveBot()
+	c.AddBot()
+	v.Advance(10 * time.Second)
+
+	history, err := c.GetOrderHistory(id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []order.Transition{
+		{From: order.PENDING, To: order.PROCESSING, At: epoch, BotID: 1, Reason: "picked up"},
+		{From: order.PROCESSING, To: order.PENDING, At: epoch.Add(4 * time.Second), BotID: 1, Reason: "bot removed"},
+		{From: order.PENDING, To: order.PROCESSING, At: epoch.Add(4 * time.Second), BotID: 2, Reason: "picked up"},
+		{From: order.PROCESSING, To: order.COMPLETE, At: epoch.Add(14 * time.Second), BotID: 2, Reason: "cooked"},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d transitions, got %+v", len(want), history)
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Errorf("Transition %d: expected %+v, got %+v", i, want[i], history[i])
+		}
+	}
+
+	if _, err := c.GetOrderHistory(42); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestCollectOrder(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v), WithPickupTimeout(time.Minute))
+
+	id := createOrder(t, c, order.Normal)
+	if _, err := c.CollectOrder(id); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition collecting a pending order, got %v", err)
+	}
+
+	c.AddBot()
+	v.Advance(10 * time.Second)
+	if complete := c.GetCompleteOrders(); len(complete) != 1 || complete[0].ID != id {
+		t.Fatalf("Expected order #%d awaiting pickup, got %+v", id, complete)
+	}
+
+	collected, err := c.CollectOrder(id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if collected.Status != order.COLLECTED || !collected.CollectedAt.Equal(epoch.Add(10*time.Second)) {
+		t.Errorf("Unexpected collected order %+v", collected)
+	}
+	if complete := c.GetCompleteOrders(); len(complete) != 0 {
+		t.Errorf("Expected the pickup area to be empty, got %+v", complete)
+	}
+
+	// The pickup timeout no longer applies once collected
+	v.Advance(time.Hour)
+	if s := orderStatus(t, c, id); s != order.COLLECTED {
+		t.Errorf("Expected order to stay COLLECTED, got %v", s)
+	}
+	if _, err := c.CollectOrder(id); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition collecting twice, got %v", err)
+	}
+	if _, err := c.CollectOrder(42); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestUncollectedOrderAbandoned(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	logs := make([]string, 0)
+	c := NewController(func(s string) { logs = append(logs, s) }, WithClock(v), WithPickupTimeout(time.Minute),
+		WithInvariantChecks())
+	abandoned := make([]Event, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventOrderAbandoned {
+			abandoned = append(abandoned, e)
+		}
+	})
+
+	id := createOrder(t, c, order.VIP)
+	c.AddBot()
+	v.Advance(10*time.Second + time.Minute - time.Millisecond)
+	if s := orderStatus(t, c, id); s != order.COMPLETE {
+		t.Fatalf("Expected order still awaiting pickup, got %v", s)
+	}
+
+	v.Advance(time.Millisecond)
+	if s := orderStatus(t, c, id); s != order.ABANDONED {
+		t.Fatalf("Expected order abandoned after the pickup timeout, got %v", s)
+	}
+	if len(abandoned) != 1 || abandoned[0].OrderID != id || abandoned[0].OrderType != order.VIP {
+		t.Errorf("Expected one abandoned event for order #%d, got %+v", id, abandoned)
+	}
+	if last := logs[len(logs)-1]; !strings.Contains(last, "Order #1 abandoned") {
+		t.Errorf("Expected abandonment to be logged, got %q", last)
+	}
+	if len(c.GetCompleteOrders()) != 0 || len(c.GetAbandonedOrders()) != 1 {
+		t.Error("Expected the order to move from the pickup area to the abandoned list")
+	}
+
+	// A late customer can still collect it
+	if _, err := c.CollectOrder(id); err != nil {
+		t.Errorf("Expected an abandoned order to be collectable, got %v", err)
+	}
+}
+
+func TestCollectedOrdersArchived(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	var export strings.Builder
+	c := newCheckedController(t, WithClock(v), WithArchive(archive.NewStore(archive.Config{MaxOrders: 2}, &export)))
+
+	c.AddBot()
+	c.AddBot()
+	for i := 0; i < 3; i++ {
+		createOrder(t, c, order.Normal)
+	}
+	v.Advance(20 * time.Second)
+	for id := 1; id <= 3; id++ {
+		if _, err := c.CollectOrder(id); err != nil {
+			t.Fatalf("Unexpected error collecting order #%d: %v", id, err)
+		}
+	}
+
+	// Collected orders leave the queues but stay queryable until evicted
+	if orders := c.GetNormalOrders(); len(orders) != 0 {
+		t.Errorf("Expected collected orders out of the queue, got %+v", orders)
+	}
+	if s := orderStatus(t, c, 3); s != order.COLLECTED {
+		t.Errorf("Expected archived order #3 COLLECTED, got %v", s)
+	}
+	if _, err := c.GetOrder(1); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected order #1 evicted, got %v", err)
+	}
+	if !strings.Contains(export.String(), `"id":1,`) {
+		t.Errorf("Expected order #1 exported before eviction, got %q", export.String())
+	}
+
+	page := c.GetArchivedOrders(archive.Query{BotID: 2})
+	if len(page.Records) != 1 || page.Records[0].ID != 2 {
+		t.Errorf("Expected only order #2 completed by Bot #2, got %+v", page.Records)
+	}
+}
+
+func TestDefaultsKeepMemoryBounded(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := NewController(func(string) {}, WithClock(v))
+	c.AddBot()
+
+	// A day and a half of orders nobody collects, one every processing time
+	for i := 0; i < 12960; i++ {
+		createOrder(t, c, order.Normal)
+		v.Advance(bot.ProcessingTime)
+	}
+
+	cfg := DefaultConfig()
+	if limit := int(cfg.PickupTimeout/bot.ProcessingTime) + 1; len(c.ready) > limit {
+		t.Errorf("Expected at most %d orders awaiting pickup, got %d", limit, len(c.ready))
+	}
+	if n := len(c.vipOrders) + len(c.normalOrders); n > 1 {
+		t.Errorf("Expected at most 1 order in the queues, got %d", n)
+	}
+	if n := c.archive.Len(); n != cfg.Archive.MaxOrders {
+		t.Errorf("Expected the archive capped at %d orders, got %d", cfg.Archive.MaxOrders, n)
+	}
+}
+
+func TestGetOrderPosition(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	first := createOrder(t, c, order.Normal)
+	second := createOrder(t, c, order.Normal)
+	vip := createOrder(t, c, order.VIP)
+
+	// Without bots there is a place but no estimate
+	p, err := c.GetOrderPosition(second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if p.Place != 3 || p.Ahead != 2 || p.Estimated {
+		t.Errorf("Expected third place behind the VIP order with no estimate, got %+v", p)
+	}
+
+	// Two bots take the VIP and the first Normal order; 4s later both have 6s left
+	c.AddBot()
+	c.AddBot()
+	v.Advance(4 * time.Second)
+	p, _ = c.GetOrderPosition(second)
+	if p.Place != 1 || !p.Estimated || p.EstimatedWait != 6*time.Second {
+		t.Errorf("Expected first place with a 6s wait, got %+v", p)
+	}
+
+	third := createOrder(t, c, order.Normal)
+	if p, _ := c.GetOrderPosition(third); p.Place != 2 || p.EstimatedWait != 6*time.Second {
+		t.Errorf("Expected the second bot to pick up order #%d in 6s, got %+v", third, p)
+	}
+	// Removing the second bot returns order #1 to the front of the queue,
+	// leaving one bot with 6s to go and two orders ahead of the newest one
+	c.RemoveBot()
+	if p, _ := c.GetOrderPosition(first); p.Place != 1 || p.EstimatedWait != 6*time.Second {
+		t.Errorf("Expected order #%d back in first place, got %+v", first, p)
+	}
+	if p, _ := c.GetOrderPosition(third); p.Place != 3 || p.EstimatedWait != 26*time.Second {
+		t.Errorf("Expected order #%d to wait 26s, got %+v", third, p)
+	}
+
+	// Orders that are not pending have no place
+	if p, _ := c.GetOrderPosition(vip); p.Place != 0 || p.Order.Status != order.PROCESSING {
+		t.Errorf("Expected no place for the processing VIP order, got %+v", p)
+	}
+	if _, err := c.GetOrderPosition(42); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestQueryOrders(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	c.AddBot()
+	createOrder(t, c, order.Normal) // 1: Bot #1, collected at 10s
+	createOrder(t, c, order.VIP)    // 2: Bot #2 from 5s
+	v.Advance(5 * time.Second)
+	c.AddBot()
+	createOrder(t, c, order.Normal) // 3: Bot #1 from 10s
+	createOrder(t, c, order.Normal) // 4: pending
+	v.Advance(5 * time.Second)
+	c.CollectOrder(1)
+
+	ids := func(p Page) string {
+		out := make([]int, len(p.Orders))
+		for i, o := range p.Orders {
+			out[i] = o.ID
+		}
+		return fmt.Sprint(out)
+	}
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{"all", Query{}, "[1 2 3 4]"},
+		{"status", Query{Statuses: []order.OrderStatus{order.PROCESSING}}, "[2 3]"},
+		{"archived status", Query{Statuses: []order.OrderStatus{order.COLLECTED}}, "[1]"},
+		{"type", Query{Types: []order.OrderType{order.Normal}}, "[1 3 4]"},
+		{"bot", Query{BotID: 1}, "[1 3]"},
+		{"created", Query{CreatedFrom: epoch.Add(time.Second), CreatedTo: epoch.Add(10 * time.Second)}, "[3 4]"},
+	}
+	for _, tt := range tests {
+		if got := ids(c.QueryOrders(tt.q)); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+
+	first := c.QueryOrders(Query{Limit: 3})
+	if ids(first) != "[1 2 3]" || first.Next != 3 {
+		t.Fatalf("Unexpected first page %+v", first)
+	}
+	if last := c.QueryOrders(Query{Limit: 3, After: first.Next}); ids(last) != "[4]" || last.Next != 0 {
+		t.Errorf("Unexpected last page %+v", last)
+	}
+}
+
+func TestGetPendingETAs(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	for i := 0; i < 3; i++ {
+		createOrder(t, c, order.Normal)
+	}
+	createOrder(t, c, order.VIP)
+	if etas := c.GetPendingETAs(); etas != nil {
+		t.Errorf("Expected no ETAs without bots, got %+v", etas)
+	}
+
+	readyIn := func() string {
+		out := make([]string, 0)
+		for _, e := range c.GetPendingETAs() {
+			out = append(out, fmt.Sprintf("#%d:%s", e.Order.ID, e.ReadyIn))
+		}
+		return strings.Join(out, " ")
+	}
+
+	// One bot takes the VIP order; the rest follow it one at a time
+	c.AddBot()
+	v.Advance(4 * time.Second)
+	if got, want := readyIn(), "#1:16s #2:26s #3:36s"; got != want {
+		t.Errorf("Expected %s with one bot, got %s", want, got)
+	}
+	if p, _ := c.GetOrderPosition(2); p.EstimatedReady != 26*time.Second {
+		t.Errorf("Expected the position to agree with the ETA, got %+v", p)
+	}
+
+	// A second bot starts order #1 now, so every estimate moves up
+	c.AddBot()
+	if got, want := readyIn(), "#2:16s #3:20s"; got != want {
+		t.Errorf("Expected %s with two bots, got %s", want, got)
+	}
+
+	// Removing it puts order #1 back at the front
+	c.RemoveBot()
+	if got, want := readyIn(), "#1:16s #2:26s #3:36s"; got != want {
+		t.Errorf("Expected %s after removing a bot, got %s", want, got)
+	}
+	if etas := c.GetPendingETAs(); !etas[0].ReadyAt.Equal(v.Now().Add(16 * time.Second)) {
+		t.Errorf("Expected ReadyAt 16s from now, got %v", etas[0].ReadyAt)
+	}
+}
+
+func TestSLAAlerts(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+	alerts := make([]string, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventSLAAtRisk || e.Type == EventSLABreached {
+			alerts = append(alerts, fmt.Sprintf("%s #%d", e.Type, e.OrderID))
+		}
+	})
+	promise := func(id int, in time.Duration) {
+		t.Helper()
+		if _, err := c.SetPromisedBy(id, epoch.Add(in)); err != nil {
+			t.Fatalf("Unexpected error promising Order #%d: %v", id, err)
+		}
+	}
+
+	// One bot: #1 is ready at 10s, #2 at 20s and #3 at 30s
+	c.AddBot()
+	promise(createOrder(t, c, order.Normal), 15*time.Second)
+	promise(createOrder(t, c, order.Normal), 15*time.Second) // at risk straight away
+	promise(createOrder(t, c, order.Normal), 40*time.Second)
+
+	// VIP orders push #3 back to 40s, still in time, then to 50s
+	createOrder(t, c, order.VIP)
+	if len(alerts) != 1 {
+		t.Errorf("Expected only Order #2 at risk, got %v", alerts)
+	}
+	createOrder(t, c, order.VIP)
+
+	v.Advance(time.Minute)
+	want := "sla_at_risk #2, sla_at_risk #3, sla_breached #2, sla_breached #3"
+	if got := strings.Join(alerts, ", "); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if stats := c.GetSLAStats(); stats != (SLAStats{Promised: 3, AtRisk: 2, Breached: 2}) {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// Completed orders cannot be given a promise; a past promise is already broken
+	if _, err := c.SetPromisedBy(1, v.Now().Add(time.Minute)); !errors.Is(err, ErrOrderCooked) {
+		t.Errorf("Expected ErrOrderCooked, got %v", err)
+	}
+	id := createOrder(t, c, order.Normal)
+	if o, err := c.SetPromisedBy(id, epoch); err != nil || !o.PromisedBy.Equal(epoch) {
+		t.Fatalf("Expected the promise recorded, got %+v, %v", o, err)
+	}
+	if stats := c.GetSLAStats(); stats.Breached != 3 {
+		t.Errorf("Expected a past promise to count as breached, got %+v", stats)
+	}
+}
+
+func TestSLAPromisesCountedEachTime(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	id := createOrder(t, c, order.Normal)
+	c.SetPromisedBy(id, epoch)
+	c.SetPromisedBy(id, epoch.Add(time.Minute))
+	c.SetPromisedBy(id, time.Time{})
+	c.SetPromisedBy(id, epoch.Add(2*time.Minute))
+	if stats := c.GetSLAStats(); stats.Promised != 3 || stats.Breached != 1 {
+		t.Errorf("Expected every promise counted, got %+v", stats)
+	}
+}
+
+func TestSLAAtRiskWhenQueueStalls(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+	alerts := make([]string, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventSLAAtRisk || e.Type == EventSLABreached {
+			alerts = append(alerts, fmt.Sprintf("%s #%d at %s", e.Type, e.OrderID, e.Time.Format("15:04:05")))
+		}
+	})
+
+	// #3 must start by 12:00:20, when the bot finishes #2; it is in time
+	c.AddBot()
+	createOrder(t, c, order.Normal)
+	createOrder(t, c, order.Normal)
+	c.SetPromisedBy(createOrder(t, c, order.Normal), epoch.Add(30*time.Second))
+	v.Advance(30 * time.Second)
+	if len(alerts) != 0 {
+		t.Errorf("Expected no alerts for an order started in time, got %v", alerts)
+	}
+
+	// #4 is in time until the bot stalls on it and #5 is never started
+	createOrder(t, c, order.Normal)
+	id := createOrder(t, c, order.Normal)
+	c.SetPromisedBy(id, v.Now().Add(20*time.Second))
+	c.timers[c.bots[0].ID].Stop()
+	v.Advance(15 * time.Second)
+	want := "sla_at_risk #5 at 12:00:40"
+	if got := strings.Join(alerts, ", "); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestScheduledOrders(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v), WithReleaseLeadTime(15*time.Minute))
+
+	catering, err := c.ScheduleOrder(order.VIP, epoch.Add(time.Hour))
+	if err != nil || catering.Status != order.SCHEDULED {
+		t.Fatalf("Expected a SCHEDULED order, got %+v, %v", catering, err)
+	}
+	createOrder(t, c, order.Normal)
+	if pending := c.GetPendingOrders(); len(pending) != 1 || len(c.GetScheduledOrders()) != 1 {
+		t.Fatalf("Expected the scheduled order kept out of the queue, got %+v", pending)
+	}
+
+	// Released 15 minutes before it is due, ahead of Normal orders as a VIP
+	v.Advance(45*time.Minute - time.Second)
+	if got := orderStatus(t, c, catering.ID); got != order.SCHEDULED {
+		t.Fatalf("Expected the order still SCHEDULED, got %s", got)
+	}
+	v.Advance(time.Second)
+	if len(c.GetScheduledOrders()) != 0 {
+		t.Fatal("Expected the order released")
+	}
+
+	// An order due within the lead time goes straight to the end of the queue
+	createOrder(t, c, order.Normal)
+	if o, _ := c.ScheduleOrder(order.Normal, v.Now().Add(10*time.Minute)); o.Status != order.PENDING {
+		t.Errorf("Expected an order due soon to be released at once, got %s", o.Status)
+	}
+	ids := make([]int, 0)
+	for _, o := range c.GetPendingOrders() {
+		ids = append(ids, o.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Errorf("Expected pending orders [1 2 3 4], got %v", ids)
+	}
+
+	// Its wait is counted from its release, not from when it was placed
+	c.AddBot()
+	v.Advance(5 * time.Second)
+	o, _ := c.GetOrder(catering.ID)
+	if !o.ReleasedAt.Equal(epoch.Add(45*time.Minute)) || o.WaitDuration() != 0 {
+		t.Errorf("Expected no wait after release, got %+v", o)
+	}
+}
+
+func TestScheduleOrderValidation(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+
+	if _, err := c.ScheduleOrder(order.OrderType(7), epoch.Add(time.Hour)); !errors.Is(err, ErrUnknownOrderType) {
+		t.Errorf("Expected ErrUnknownOrderType, got %v", err)
+	}
+	for _, due := range []time.Time{epoch, epoch.Add(-time.Minute)} {
+		if _, err := c.ScheduleOrder(order.Normal, due); !errors.Is(err, ErrDueNotInFuture) {
+			t.Errorf("Expected ErrDueNotInFuture for %v, got %v", due, err)
+		}
+	}
+
+	// Rejected orders use no order ID
+	if o, err := c.ScheduleOrder(order.VIP, epoch.Add(time.Second)); err != nil || o.ID != 1 {
+		t.Errorf("Expected order #1 scheduled, got %+v, %v", o, err)
+	}
+}
+
+func TestHoldAndMoveOrders(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+	started := make([]int, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventOrderStarted {
+			started = append(started, e.OrderID)
+		}
+	})
+	for i := 0; i < 3; i++ {
+		createOrder(t, c, order.Normal)
+	}
+	createOrder(t, c, order.VIP)
+
+	if o, err := c.HoldOrder(1); err != nil || o.Status != order.HELD {
+		t.Fatalf("Expected Order #1 HELD, got %+v, %v", o, err)
+	}
+
+	// #3 moves ahead of #2, then ahead of the held #1
+	for i := 0; i < 2; i++ {
+		if _, err := c.MoveOrderUp(3); err != nil {
+			t.Fatalf("Unexpected error moving Order #3 up: %v", err)
+		}
+	}
+	if _, err := c.MoveOrderUp(3); !errors.Is(err, ErrCannotMove) {
+		t.Errorf("Expected ErrCannotMove for the first order, got %v", err)
+	}
+	if _, err := c.MoveOrderDown(4); !errors.Is(err, ErrCannotMove) {
+		t.Errorf("Expected ErrCannotMove for the only VIP order, got %v", err)
+	}
+
+	// Bots skip the held order
+	c.AddBot()
+	v.Advance(time.Minute)
+	if fmt.Sprint(started) != "[4 3 2]" || orderStatus(t, c, 1) != order.HELD {
+		t.Fatalf("Expected orders 4, 3 and 2 started and #1 still held, got %v", started)
+	}
+
+	// Resuming it hands it to the idle bot
+	if _, err := c.ResumeOrder(1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := orderStatus(t, c, 1); got != order.PROCESSING {
+		t.Errorf("Expected the resumed order PROCESSING, got %s", got)
+	}
+	if _, err := c.HoldOrder(1); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition holding a processing order, got %v", err)
+	}
+
+	// Every manager action is in the order's history
+	history, _ := c.GetOrderHistory(3)
+	if len(history) < 2 || history[1].Reason != "moved up by manager, ahead of Order #1" || history[1].From != order.PENDING {
+		t.Errorf("Expected the moves recorded, got %+v", history)
+	}
+	history, _ = c.GetOrderHistory(1)
+	if history[0].To != order.HELD || history[0].Reason != "held by manager" || history[2].Reason != "resumed by manager" {
+		t.Errorf("Expected the hold recorded, got %+v", history)
+	}
+}
+
+func TestPromoteAndDemoteOrders(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	c := newCheckedController(t, WithClock(v))
+	changes := make([]string, 0)
+	started := make([]int, 0)
+	c.Subscribe(func(e Event) {
+		switch e.Type {
+		case EventOrderPromoted, EventOrderDemoted:
+			changes = append(changes, fmt.Sprintf("#%d %s", e.OrderID, e.OrderType))
+		case EventOrderStarted:
+			started = append(started, e.OrderID)
+		}
+	})
+	pending := func() string {
+		ids := make([]int, 0)
+		for _, o := range c.GetPendingOrders() {
+			ids = append(ids, o.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+	createOrder(t, c, order.VIP)
+	for i := 0; i < 3; i++ {
+		createOrder(t, c, order.Normal)
+	}
+	createOrder(t, c, order.VIP)
+
+	// A promoted order goes behind the VIP orders already waiting
+	if o, err := c.PromoteOrder(3); err != nil || o.Type != order.VIP {
+		t.Fatalf("Expected Order #3 promoted, got %+v, %v", o, err)
+	}
+	if got := pending(); got != "[1 5 3 2 4]" {
+		t.Errorf("Expected pending orders [1 5 3 2 4], got %s", got)
+	}
+	if _, err := c.PromoteOrder(1); !errors.Is(err, ErrCannotChangeType) {
+		t.Errorf("Expected ErrCannotChangeType promoting a VIP order, got %v", err)
+	}
+
+	// A demoted order goes back among Normal orders in creation order
+	c.DemoteOrder(3)
+	c.DemoteOrder(1)
+	if got := pending(); got != "[5 1 2 3 4]" {
+		t.Errorf("Expected pending orders [5 1 2 3 4], got %s", got)
+	}
+	if got, want := strings.Join(changes, ", "), "#3 VIP, #3 Normal, #1 Normal"; got != want {
+		t.Errorf("Expected events %s, got %s", want, got)
+	}
+
+	c.AddBot()
+	if _, err := c.PromoteOrder(1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.DemoteOrder(5); !errors.Is(err, ErrCannotChangeType) {
+		t.Errorf("Expected ErrCannotChangeType for an order being processed, got %v", err)
+	}
+	v.Advance(time.Minute)
+	if fmt.Sprint(started) != "[5 1 2 3 4]" {
+		t.Errorf("Expected orders started in order [5 1 2 3 4], got %v", started)
+	}
+
+	history, _ := c.GetOrderHistory(3)
+	if history[0].Reason != "promoted to VIP by manager" || history[1].Reason != "demoted to Normal by manager" {
+		t.Errorf("Expected the tier changes recorded, got %+v", history)
+	}
+}
+
+func TestQueueLimitsRejectOrders(t *testing.T) {
+	c := newCheckedController(t, WithQueueLimits(QueueLimits{MaxVIP: 1, MaxNormal: 2, MaxTotal: 2}))
+	rejected := make([]string, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventOrderRejected {
+			rejected = append(rejected, fmt.Sprintf("%s: %s", e.OrderType, e.Detail))
+		}
+	})
+
+	createOrder(t, c, order.VIP)
+	if _, err := c.CreateVIPOrder(); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull over the VIP limit, got %v", err)
+	}
+	createOrder(t, c, order.Normal)
+	if _, err := c.CreateNormalOrder(); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull over the total limit, got %v", err)
+	}
+
+	// Rejected orders take no order number
+	c.AddBot()
+	if id := createOrder(t, c, order.Normal); id != 3 {
+		t.Errorf("Expected the next order to be #3, got #%d", id)
+	}
+	want := "VIP: 1/1 VIP orders waiting, Normal: 2/2 orders waiting"
+	if got := strings.Join(rejected, ", "); got != want {
+		t.Errorf("Expected rejections %q, got %q", want, got)
+	}
+
+	// Held orders still take up room
+	c.HoldOrder(3)
+	if _, err := c.CreateVIPOrder(); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull with a held order waiting, got %v", err)
+	}
+}
+
+func TestWaitlistAndKitchenBusy(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	limits := QueueLimits{MaxVIP: 1, MaxNormal: 2, Waitlist: true, BusyThreshold: 100}
+	c := newCheckedController(t, WithClock(v), WithQueueLimits(limits))
+	events := make([]string, 0)
+	started := make([]int, 0)
+	c.Subscribe(func(e Event) {
+		switch e.Type {
+		case EventOrderWaitlisted, EventOrderAdmitted:
+			events = append(events, fmt.Sprintf("%s #%d", e.Type, e.OrderID))
+		case EventKitchenBusy, EventKitchenAvailable:
+			events = append(events, fmt.Sprintf("%s %s", e.Type, e.Detail))
+		case EventOrderStarted:
+			started = append(started, e.OrderID)
+		}
+	})
+
+	createOrder(t, c, order.Normal)
+	createOrder(t, c, order.Normal)
+	o, err := c.CreateNormalOrder()
+	if err != nil || o.Status != order.WAITLISTED {
+		t.Fatalf("Expected Order #3 waitlisted, got %+v, %v", o, err)
+	}
+	createOrder(t, c, order.VIP)
+	createOrder(t, c, order.VIP)
+	createOrder(t, c, order.Normal)
+	if !c.IsKitchenBusy() {
+		t.Error("Expected the kitchen to be busy with 3 of 3 orders waiting")
+	}
+
+	var ids []int
+	for _, w := range c.GetWaitlistedOrders() {
+		ids = append(ids, w.ID)
+	}
+	if fmt.Sprint(ids) != "[5 3 6]" {
+		t.Errorf("Expected waitlist [5 3 6], got %v", ids)
+	}
+	if _, err := c.PromoteOrder(3); !errors.Is(err, ErrCannotChangeType) {
+		t.Errorf("Expected ErrCannotChangeType for a waitlisted order, got %v", err)
+	}
+
+	// Waitlisted orders are admitted as bots take orders, VIP first
+	c.AddBot()
+	v.Advance(time.Minute)
+	if fmt.Sprint(started) != "[4 5 1 2 3 6]" {
+		t.Errorf("Expected orders started in order [4 5 1 2 3 6], got %v", started)
+	}
+	want := "order_waitlisted #3, kitchen_busy 3/3 orders waiting, order_waitlisted #5, order_waitlisted #6, " +
+		"order_admitted #5, kitchen_available 2/3 orders waiting, order_admitted #3, order_admitted #6"
+	if got := strings.Join(events, ", "); got != want {
+		t.Errorf("Expected events:\n%s\ngot:\n%s", want, got)
+	}
+	if c.IsKitchenBusy() || len(c.GetWaitlistedOrders()) != 0 {
+		t.Error("Expected the kitchen available with an empty waitlist")
+	}
+
+	history, _ := c.GetOrderHistory(3)
+	if history[0].From != order.WAITLISTED || history[0].Reason != "admitted from the waitlist" {
+		t.Errorf("Expected Order #3 admitted from the waitlist first, got %+v", history[0])
+	}
+}
+
+func TestWaitlistLimit(t *testing.T) {
+	v := clock.NewVirtual(epoch)
+	limits := QueueLimits{MaxTotal: 1, Waitlist: true, MaxWaitlist: 2}
+	c := newCheckedController(t, WithClock(v), WithQueueLimits(limits))
+	rejected := make([]string, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventOrderRejected {
+			rejected = append(rejected, e.Detail)
+		}
+	})
+
+	createOrder(t, c, order.Normal)
+	createOrder(t, c, order.Normal)
+	createOrder(t, c, order.VIP)
+	if n := len(c.GetWaitlistedOrders()); n != 2 {
+		t.Fatalf("Expected 2 waitlisted orders, got %d", n)
+	}
+	if _, err := c.CreateVIPOrder(); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull with the waitlist full, got %v", err)
+	}
+	if len(rejected) != 1 || rejected[0] != "1/1 orders waiting, 2/2 orders waitlisted" {
+		t.Errorf("Expected one rejection for the full waitlist, got %v", rejected)
+	}
+
+	// Admitting an order makes room on the waitlist
+	c.AddBot()
+	if n := len(c.GetWaitlistedOrders()); n != 1 {
+		t.Fatalf("Expected 1 waitlisted order after an admission, got %d", n)
+	}
+	if o, err := c.CreateNormalOrder(); err != nil || o.Status != order.WAITLISTED {
+		t.Errorf("Expected the new order waitlisted, got %+v, %v", o, err)
+	}
+}
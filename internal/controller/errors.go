@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"assignment/internal/order"
+	"errors"
+)
+
+// Errors returned by the controller. They are wrapped with details such as
+// the order ID, so compare with errors.Is.
+var (
+	// ErrNoBots is returned when removing a bot while there are none
+	ErrNoBots = errors.New("no bots available")
+
+	// ErrOrderNotFound is returned for an order ID the controller does not know
+	ErrOrderNotFound = errors.New("order not found")
+
+	// ErrInvalidTransition is returned when an order cannot move to the
+	// requested status from its current one
+	ErrInvalidTransition = order.ErrInvalidTransition
+
+	// ErrOrderCooked is returned when changing an order that is already complete
+	ErrOrderCooked = errors.New("order is already cooked")
+
+	// ErrCannotMove is returned when an order cannot move within its queue
+	ErrCannotMove = errors.New("order cannot be moved")
+
+	// ErrCannotChangeType is returned when an order cannot be promoted or demoted
+	ErrCannotChangeType = errors.New("order type cannot be changed")
+
+	// ErrUnknownOrderType is returned for an order type other than Normal or VIP
+	ErrUnknownOrderType = errors.New("unknown order type")
+
+	// ErrDueNotInFuture is returned when scheduling an order due at or before the current time
+	ErrDueNotInFuture = errors.New("due time is not in the future")
+
+	// ErrQueueFull is returned when a new order would exceed the pending queue limits
+	ErrQueueFull = errors.New("order queue is full")
+
+	// ErrShutDown is returned by every change requested after Shutdown
+	ErrShutDown = errors.New("controller is shut down")
+)
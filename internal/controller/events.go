@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"assignment/internal/order"
+	"fmt"
+	"time"
+)
+
+// EventType identifies what happened in the controller
+type EventType string
+
+const (
+	EventOrderCreated    EventType = "order_created"
+	EventOrderScheduled  EventType = "order_scheduled"  // created for later, not queued yet
+	EventOrderReleased   EventType = "order_released"   // scheduled order joined the queue
+	EventOrderWaitlisted EventType = "order_waitlisted" // created while the queue was full, not queued yet
+	EventOrderAdmitted   EventType = "order_admitted"   // waitlisted order joined the queue
+	EventOrderHeld       EventType = "order_held"
+	EventOrderResumed    EventType = "order_resumed"
+	EventOrderMovedUp    EventType = "order_moved_up"   // swapped with the waiting order ahead of it
+	EventOrderMovedDown  EventType = "order_moved_down" // swapped with the waiting order behind it
+	EventOrderPromoted   EventType = "order_promoted"   // Normal order moved to the VIP queue; OrderType is the new type
+	EventOrderDemoted    EventType = "order_demoted"    // VIP order moved to the Normal queue; OrderType is the new type
+	EventOrderStarted    EventType = "order_started"
+	EventOrderCompleted  EventType = "order_completed"
+	EventOrderRequeued   EventType = "order_requeued"
+	EventOrderCollected  EventType = "order_collected"
+	EventOrderAbandoned  EventType = "order_abandoned"
+	EventBotAdded        EventType = "bot_added"
+	EventBotRemoved      EventType = "bot_removed"
+
+	// EventSLAAtRisk is emitted when an order is expected to miss its
+	// promised time, and EventSLABreached when it has missed it
+	EventSLAAtRisk   EventType = "sla_at_risk"
+	EventSLABreached EventType = "sla_breached"
+
+	// EventOrderRejected is emitted when a new order is turned away because
+	// the queue is full; no order is created, so OrderID is 0
+	EventOrderRejected EventType = "order_rejected"
+
+	// EventKitchenBusy is emitted when the waiting orders reach the busy
+	// threshold, and EventKitchenAvailable when they drop back below it
+	EventKitchenBusy      EventType = "kitchen_busy"
+	EventKitchenAvailable EventType = "kitchen_available"
+
+	// EventInvariantViolated is emitted, with invariant checks enabled, right
+	// after the event that broke a scheduling rule
+	EventInvariantViolated EventType = "invariant_violated"
+)
+
+// Event describes a single state change in the controller.
+// Fields that do not apply to an event type are left at their zero value.
+type Event struct {
+	Type      EventType
+	Time      time.Time
+	OrderID   int
+	OrderType order.OrderType
+	BotID     int
+	Wait      time.Duration // queue wait, set on started and completed events
+	Cook      time.Duration // processing time, set on completed events
+	Detail    string        // description, set on scheduled, waitlisted, rejected, moved, kitchen load, invariant violation and SLA events
+}
+
+// Subscribe registers fn to receive every controller event.
+// Handlers are called synchronously, in event order, while the controller's
+// lock is held: every other controller call waits until they return. They
+// must therefore be quick and must not block or call any Controller method,
+// which would deadlock. Hand events to a goroutine for slow work such as
+// network I/O.
+func (c *Controller) Subscribe(fn func(Event)) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	c.subscribers = append(c.subscribers, fn)
+}
+
+// emit delivers an event to all subscribers, followed by any invariant
+// violations it reveals.
+// Must be called with lock held
+func (c *Controller) emit(e Event) {
+	c.deliver(e)
+	if c.checker == nil {
+		return
+	}
+
+	violations := append(c.checker.Observe(e), c.checkState(e.Time)...)
+	for _, v := range violations {
+		c.logger(fmt.Sprintf("[%s] Invariant violated: %s", v.Time.Format(c.timestampFormat), v.Message))
+		c.deliver(Event{Type: EventInvariantViolated, Time: v.Time, OrderID: v.OrderID, OrderType: v.OrderType,
+			BotID: v.BotID, Detail: v.Message})
+	}
+}
+
+// deliver calls every subscriber with the event.
+// Must be called with lock held, so events reach subscribers in order
+func (c *Controller) deliver(e Event) {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	for _, fn := range c.subscribers {
+		fn(e)
+	}
+}
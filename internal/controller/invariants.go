@@ -0,0 +1,333 @@
+package controller
+
+import (
+	"assignment/internal/order"
+	"fmt"
+	"time"
+)
+
+// Violation is a broken scheduling rule
+type Violation struct {
+	Time      time.Time
+	OrderID   int
+	OrderType order.OrderType
+	BotID     int
+	Message   string
+}
+
+// orderState is what the checker knows about one order
+type orderState struct {
+	typ    order.OrderType
+	status order.OrderStatus
+	botID  int
+}
+
+// InvariantChecker follows events one at a time and reports those that break the
+// scheduling rules: IDs increase, VIP orders start before Normal ones, orders
+// start in queue order within their tier, a bot holds at most one order,
+// an order completes once, a removed bot's order returns to PENDING, only
+// scheduled orders are released, only waitlisted orders are admitted, only
+// pending orders are held, and only completed orders are collected or abandoned.
+//
+// Orders join the end of their tier's queue when created, or when released
+// or admitted if they were scheduled or waitlisted, and a manager's move
+// swaps two waiting orders. A promoted order joins the end of the VIP queue;
+// a demoted one goes ahead of the waiting Normal orders created after it.
+// Requeued and held orders keep their place, so an order is only allowed to
+// start when no pending order of the same or a higher tier is ahead of it.
+type InvariantChecker struct {
+	lastID int
+	orders map[int]*orderState
+	queues map[order.OrderType][]int // uncooked order IDs per tier, in queue order
+	bots   map[int]int               // order held by each bot
+}
+
+// NewInvariantChecker creates a checker that has seen no events
+func NewInvariantChecker() *InvariantChecker {
+	return &InvariantChecker{
+		orders: make(map[int]*orderState),
+		queues: make(map[order.OrderType][]int),
+		bots:   make(map[int]int),
+	}
+}
+
+// Observe applies an event and returns the violations it raises
+func (c *InvariantChecker) Observe(e Event) []Violation {
+	violations := make([]Violation, 0)
+	report := func(format string, args ...any) {
+		violations = append(violations, Violation{Time: e.Time, OrderID: e.OrderID, OrderType: e.OrderType, BotID: e.BotID,
+			Message: fmt.Sprintf(format, args...)})
+	}
+
+	if e.Type == EventBotRemoved {
+		if held, ok := c.bots[e.BotID]; ok {
+			report("Bot #%d removed while processing Order #%d without returning it to PENDING", e.BotID, held)
+			delete(c.bots, e.BotID)
+		}
+		return violations
+	}
+	if e.OrderID == 0 || e.Type == EventInvariantViolated {
+		return violations
+	}
+
+	if e.Type == EventOrderCreated || e.Type == EventOrderScheduled || e.Type == EventOrderWaitlisted {
+		if e.OrderID <= c.lastID {
+			report("Order #%d created after Order #%d; order IDs must increase", e.OrderID, c.lastID)
+		} else {
+			c.lastID = e.OrderID
+		}
+		if _, ok := c.orders[e.OrderID]; !ok {
+			switch e.Type {
+			case EventOrderScheduled:
+				c.orders[e.OrderID] = &orderState{typ: e.OrderType, status: order.SCHEDULED}
+			case EventOrderWaitlisted:
+				c.orders[e.OrderID] = &orderState{typ: e.OrderType, status: order.WAITLISTED}
+			default:
+				c.orders[e.OrderID] = &orderState{typ: e.OrderType, status: order.PENDING}
+				c.queues[e.OrderType] = append(c.queues[e.OrderType], e.OrderID)
+			}
+		}
+		return violations
+	}
+
+	o, ok := c.orders[e.OrderID]
+	if !ok {
+		report("Order #%d %s before it was created", e.OrderID, e.Type)
+		return violations
+	}
+
+	switch e.Type {
+	case EventOrderReleased:
+		if o.status != order.SCHEDULED {
+			report("Order #%d released while %s", e.OrderID, o.status)
+			return violations
+		}
+		o.status = order.PENDING
+		c.queues[o.typ] = append(c.queues[o.typ], e.OrderID)
+
+	case EventOrderAdmitted:
+		if o.status != order.WAITLISTED {
+			report("Order #%d admitted while %s", e.OrderID, o.status)
+			return violations
+		}
+		o.status = order.PENDING
+		c.queues[o.typ] = append(c.queues[o.typ], e.OrderID)
+
+	case EventOrderHeld:
+		if o.status != order.PENDING {
+			report("Order #%d held while %s", e.OrderID, o.status)
+			return violations
+		}
+		o.status = order.HELD
+
+	case EventOrderResumed:
+		if o.status != order.HELD {
+			report("Order #%d resumed while %s", e.OrderID, o.status)
+			return violations
+		}
+		o.status = order.PENDING
+
+	case EventOrderMovedUp, EventOrderMovedDown:
+		if o.status != order.PENDING && o.status != order.HELD {
+			report("Order #%d moved while %s", e.OrderID, o.status)
+			return violations
+		}
+		step := -1
+		if e.Type == EventOrderMovedDown {
+			step = 1
+		}
+		if !c.swap(o.typ, e.OrderID, step) {
+			report("Order #%d moved past the end of the %s queue", e.OrderID, o.typ)
+		}
+
+	case EventOrderPromoted, EventOrderDemoted:
+		if o.status != order.PENDING && o.status != order.HELD {
+			report("Order #%d changed to %s while %s", e.OrderID, e.OrderType, o.status)
+			return violations
+		}
+		c.dequeue(o.typ, e.OrderID)
+		o.typ = order.VIP
+		if e.Type == EventOrderDemoted {
+			o.typ = order.Normal
+		}
+		c.enqueue(o.typ, e.OrderID, e.Type == EventOrderDemoted)
+
+	case EventOrderStarted:
+		switch o.status {
+		case order.PROCESSING:
+			report("Order #%d started by Bot #%d while already being processed by Bot #%d", e.OrderID, e.BotID, o.botID)
+			return violations
+		case order.COMPLETE, order.COLLECTED, order.ABANDONED:
+			report("Order #%d started by Bot #%d after it was completed", e.OrderID, e.BotID)
+			return violations
+		case order.SCHEDULED:
+			report("Order #%d started by Bot #%d before it was released", e.OrderID, e.BotID)
+			return violations
+		case order.HELD:
+			report("Order #%d started by Bot #%d while on hold", e.OrderID, e.BotID)
+			return violations
+		case order.WAITLISTED:
+			report("Order #%d started by Bot #%d before it was admitted", e.OrderID, e.BotID)
+			return violations
+		}
+		if held, busy := c.bots[e.BotID]; busy {
+			report("Bot #%d started Order #%d while still processing Order #%d", e.BotID, e.OrderID, held)
+		}
+		if vip := c.firstPending(order.VIP); o.typ == order.Normal && vip != 0 {
+			report("Normal Order #%d started while VIP Order #%d was pending", e.OrderID, vip)
+		}
+		if first := c.firstPending(o.typ); first != 0 && first != e.OrderID {
+			report("%s Order #%d started before earlier %s Order #%d", o.typ, e.OrderID, o.typ, first)
+		}
+		o.status = order.PROCESSING
+		o.botID = e.BotID
+		c.bots[e.BotID] = e.OrderID
+
+	case EventOrderCompleted:
+		switch {
+		case o.status.IsCooked():
+			report("Order #%d completed twice", e.OrderID)
+			return violations
+		case o.status != order.PROCESSING:
+			report("Order #%d completed by Bot #%d without being started", e.OrderID, e.BotID)
+		case o.botID != e.BotID:
+			report("Order #%d completed by Bot #%d but was being processed by Bot #%d", e.OrderID, e.BotID, o.botID)
+		}
+		c.release(o)
+		c.dequeue(o.typ, e.OrderID)
+		o.status = order.COMPLETE
+
+	case EventOrderRequeued:
+		if o.status != order.PROCESSING {
+			report("Order #%d returned to PENDING while %s", e.OrderID, o.status)
+			return violations
+		}
+		c.release(o)
+		o.status = order.PENDING
+
+	case EventOrderCollected:
+		if o.status != order.COMPLETE && o.status != order.ABANDONED {
+			report("Order #%d collected while %s", e.OrderID, o.status)
+		}
+		o.status = order.COLLECTED
+
+	case EventOrderAbandoned:
+		if o.status != order.COMPLETE {
+			report("Order #%d abandoned while %s", e.OrderID, o.status)
+		}
+		o.status = order.ABANDONED
+	}
+	return violations
+}
+
+// release frees the bot holding o
+func (c *InvariantChecker) release(o *orderState) {
+	if o.botID != 0 {
+		delete(c.bots, o.botID)
+		o.botID = 0
+	}
+}
+
+// firstPending returns the pending order bots must take next from a tier's
+// queue, 0 if none is pending
+func (c *InvariantChecker) firstPending(typ order.OrderType) int {
+	for _, id := range c.queues[typ] {
+		if c.orders[id].status == order.PENDING {
+			return id
+		}
+	}
+	return 0
+}
+
+// swap exchanges an order with the next waiting order in direction step
+// (-1 towards the front), returning false if there is none
+func (c *InvariantChecker) swap(typ order.OrderType, id, step int) bool {
+	ids := c.queues[typ]
+	i := 0
+	for i < len(ids) && ids[i] != id {
+		i++
+	}
+	for j := i + step; j >= 0 && j < len(ids); j += step {
+		if s := c.orders[ids[j]].status; s == order.PENDING || s == order.HELD {
+			ids[i], ids[j] = ids[j], ids[i]
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue adds an order to the end of a tier's queue or, byID, ahead of the
+// first waiting order with a higher ID
+func (c *InvariantChecker) enqueue(typ order.OrderType, id int, byID bool) {
+	ids := c.queues[typ]
+	i := len(ids)
+	for j, queued := range ids {
+		if s := c.orders[queued].status; byID && queued > id && (s == order.PENDING || s == order.HELD) {
+			i = j
+			break
+		}
+	}
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	c.queues[typ] = ids
+}
+
+// dequeue removes an order from its tier's queue
+func (c *InvariantChecker) dequeue(typ order.OrderType, id int) {
+	ids := c.queues[typ]
+	for i, queued := range ids {
+		if queued == id {
+			c.queues[typ] = append(ids[:i], ids[i+1:]...)
+			return
+		}
+	}
+}
+
+// checkState verifies that the queues and bots agree: every busy bot holds a
+// PROCESSING order, no order is held by two bots, and every PROCESSING order
+// is held by an active bot.
+// Must be called with lock held
+func (c *Controller) checkState(now time.Time) []Violation {
+	violations := make([]Violation, 0)
+	report := func(o *order.Order, botID int, format string, args ...any) {
+		v := Violation{Time: now, BotID: botID, Message: fmt.Sprintf(format, args...)}
+		if o != nil {
+			v.OrderID, v.OrderType = o.ID, o.Type
+		}
+		violations = append(violations, v)
+	}
+
+	holders := make(map[*order.Order]int)
+	for _, b := range c.bots {
+		o := b.CurrentOrder
+		switch {
+		case b.IsProcessing() && o == nil:
+			report(nil, b.ID, "Bot #%d is PROCESSING without an order", b.ID)
+		case b.IsIdle() && o != nil:
+			report(o, b.ID, "Bot #%d is IDLE but holds Order #%d", b.ID, o.ID)
+		case o != nil && o.Status != order.PROCESSING:
+			report(o, b.ID, "Bot #%d holds Order #%d which is %s", b.ID, o.ID, o.Status)
+		}
+		if o == nil {
+			continue
+		}
+		if other, ok := holders[o]; ok {
+			report(o, b.ID, "Order #%d is held by Bot #%d and Bot #%d", o.ID, other, b.ID)
+		}
+		holders[o] = b.ID
+	}
+	for _, b := range c.retiredBots {
+		if b.CurrentOrder != nil {
+			report(b.CurrentOrder, b.ID, "Removed Bot #%d still holds Order #%d", b.ID, b.CurrentOrder.ID)
+		}
+	}
+	for _, queue := range [][]*order.Order{c.vipOrders, c.normalOrders} {
+		for _, o := range queue {
+			if o.Status == order.PROCESSING && holders[o] == 0 {
+				report(o, 0, "Order #%d is PROCESSING but no bot holds it", o.ID)
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"assignment/internal/order"
+	"fmt"
+)
+
+// HoldOrder sets a pending order aside: bots skip it, but it keeps its place
+// in its tier's queue until ResumeOrder. It returns ErrInvalidTransition for
+// orders that are not pending.
+func (c *Controller) HoldOrder(id int) (order.View, error) {
+	return c.changeHold(id, order.HELD, "held", EventOrderHeld)
+}
+
+// ResumeOrder returns a held order to PENDING at the place it was held and
+// hands it to an idle bot if it is next. It returns ErrInvalidTransition for
+// orders that are not held.
+func (c *Controller) ResumeOrder(id int) (order.View, error) {
+	return c.changeHold(id, order.PENDING, "resumed", EventOrderResumed)
+}
+
+// changeHold moves an order between PENDING and HELD
+func (c *Controller) changeHold(id int, to order.OrderStatus, action string, eventType EventType) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	o := c.findOrder(id)
+	if o == nil {
+		return order.View{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+	now := c.clock.Now()
+	if err := o.Transition(to, now, 0, action+" by manager"); err != nil {
+		return o.View(), err
+	}
+
+	c.logger(fmt.Sprintf("[%s] Order #%d %s - Status: %s", now.Format(c.timestampFormat), o.ID, action, o.Status))
+	c.emit(Event{Type: eventType, Time: now, OrderID: o.ID, OrderType: o.Type})
+
+	// A resumed order may be next, and a held one changes every estimate behind it
+	c.assignPendingOrders()
+
+	return o.View(), nil
+}
+
+// MoveOrderUp swaps a pending or held order with the waiting order just
+// ahead of it in its tier's queue. Orders being processed or complete are
+// not waiting and keep their place. It returns ErrCannotMove when the order
+// is not waiting or is already first.
+func (c *Controller) MoveOrderUp(id int) (order.View, error) {
+	return c.moveOrder(id, -1)
+}
+
+// MoveOrderDown swaps a pending or held order with the waiting order just
+// behind it in its tier's queue. It returns ErrCannotMove when the order is
+// not waiting or is already last.
+func (c *Controller) MoveOrderDown(id int) (order.View, error) {
+	return c.moveOrder(id, 1)
+}
+
+// moveOrder swaps an order with the next waiting order in direction step
+// (-1 towards the front, 1 towards the back) and records the move in the
+// order's history
+func (c *Controller) moveOrder(id, step int) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	o := c.findOrder(id)
+	if o == nil {
+		return order.View{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+	if !isWaiting(o) {
+		return o.View(), fmt.Errorf("%w: #%d is %s", ErrCannotMove, id, o.Status)
+	}
+
+	queue := c.normalOrders
+	if o.IsVIP() {
+		queue = c.vipOrders
+	}
+	i := indexOf(queue, o)
+	j := i + step
+	for j >= 0 && j < len(queue) && !isWaiting(queue[j]) {
+		j += step
+	}
+	direction, relation := "up", "ahead of"
+	if step > 0 {
+		direction, relation = "down", "behind"
+	}
+	if j < 0 || j >= len(queue) {
+		return o.View(), fmt.Errorf("%w: #%d cannot move %s any further", ErrCannotMove, id, direction)
+	}
+	other := queue[j]
+	queue[i], queue[j] = other, o
+
+	now := c.clock.Now()
+	detail := fmt.Sprintf("%s Order #%d", relation, other.ID)
+	o.Note(now, 0, fmt.Sprintf("moved %s by manager, %s", direction, detail))
+	other.Note(now, 0, fmt.Sprintf("Order #%d moved %s by manager", o.ID, direction))
+
+	eventType := EventOrderMovedUp
+	if step > 0 {
+		eventType = EventOrderMovedDown
+	}
+	c.logger(fmt.Sprintf("[%s] Order #%d moved %s - %s", now.Format(c.timestampFormat), o.ID, direction, detail))
+	c.emit(Event{Type: eventType, Time: now, OrderID: o.ID, OrderType: o.Type, Detail: detail})
+
+	// The swap changes the estimates of both orders
+	c.assignPendingOrders()
+
+	return o.View(), nil
+}
+
+// PromoteOrder upgrades a waiting Normal order to VIP, e.g. when the
+// customer shows a membership card after ordering. The order joins the end
+// of the VIP queue, behind every VIP order already there. It returns
+// ErrCannotChangeType for orders that are VIP already or no longer waiting.
+func (c *Controller) PromoteOrder(id int) (order.View, error) {
+	return c.changeType(id, order.VIP)
+}
+
+// DemoteOrder turns a waiting VIP order, e.g. one entered as VIP by
+// mistake, back into a Normal order. It takes the place among waiting Normal
+// orders it would have had if it had been created Normal: ahead of those
+// created after it. It returns ErrCannotChangeType for orders that are
+// Normal already or no longer waiting.
+func (c *Controller) DemoteOrder(id int) (order.View, error) {
+	return c.changeType(id, order.Normal)
+}
+
+// changeType moves a waiting order to the other tier's queue
+func (c *Controller) changeType(id int, to order.OrderType) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	o := c.findOrder(id)
+	if o == nil {
+		return order.View{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+	if o.Type == to {
+		return o.View(), fmt.Errorf("%w: #%d is already %s", ErrCannotChangeType, id, to)
+	}
+	if !isWaiting(o) {
+		return o.View(), fmt.Errorf("%w: #%d is %s", ErrCannotChangeType, id, o.Status)
+	}
+
+	now := c.clock.Now()
+	action, eventType := "promoted", EventOrderPromoted
+	if to == order.VIP {
+		c.normalOrders = remove(c.normalOrders, o)
+		c.vipOrders = append(c.vipOrders, o)
+	} else {
+		action, eventType = "demoted", EventOrderDemoted
+		c.vipOrders = remove(c.vipOrders, o)
+		c.normalOrders = insertByID(c.normalOrders, o)
+	}
+	o.ChangeType(to, now, fmt.Sprintf("%s to %s by manager", action, to))
+
+	c.logger(fmt.Sprintf("[%s] Order #%d %s to %s", now.Format(c.timestampFormat), o.ID, action, to))
+	c.emit(Event{Type: eventType, Time: now, OrderID: o.ID, OrderType: o.Type})
+
+	// The order now goes before or after every order of the other tier
+	c.assignPendingOrders()
+
+	return o.View(), nil
+}
+
+// insertByID inserts o ahead of the first waiting order in queue with a
+// higher ID, or at the end if there is none
+func insertByID(queue []*order.Order, o *order.Order) []*order.Order {
+	i := 0
+	for i < len(queue) && !(isWaiting(queue[i]) && queue[i].ID > o.ID) {
+		i++
+	}
+	queue = append(queue, nil)
+	copy(queue[i+1:], queue[i:])
+	queue[i] = o
+	return queue
+}
+
+// GetHeldOrders returns orders on hold, VIP first
+func (c *Controller) GetHeldOrders() []order.View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held := make([]order.View, 0)
+	held = appendViews(held, c.vipOrders, isStatus(order.HELD))
+	held = appendViews(held, c.normalOrders, isStatus(order.HELD))
+	return held
+}
+
+// isWaiting returns true for orders in a queue that no bot has taken yet
+func isWaiting(o *order.Order) bool {
+	return o.Status == order.PENDING || o.Status == order.HELD
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"assignment/internal/archive"
+	"assignment/internal/order"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CollectOrder marks a completed order as COLLECTED when the customer picks
+// it up and moves it to the archive. Abandoned orders can still be collected
+// until the archive evicts them. It returns ErrInvalidTransition for orders
+// that are not ready yet or already collected.
+func (c *Controller) CollectOrder(id int) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	o := c.findOrder(id)
+	if o == nil {
+		return order.View{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+
+	now := c.clock.Now()
+	if err := o.Transition(order.COLLECTED, now, 0, "collected by customer"); err != nil {
+		return o.View(), err
+	}
+	if t, ok := c.pickupTimers[o.ID]; ok {
+		t.Stop()
+		delete(c.pickupTimers, o.ID)
+	}
+
+	c.logger(fmt.Sprintf("[%s] Order #%d collected - Status: %s", now.Format(c.timestampFormat), o.ID, o.Status))
+	c.emit(Event{Type: EventOrderCollected, Time: now, OrderID: o.ID, OrderType: o.Type})
+	c.archiveOrder(o, now)
+
+	return o.View(), nil
+}
+
+// GetAbandonedOrders returns archived orders that were not collected in
+// time, VIP first
+func (c *Controller) GetAbandonedOrders() []order.View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	abandoned := make([]order.View, 0)
+	for _, r := range c.archive.Query(archive.Query{}).Records {
+		if r.Status == order.ABANDONED {
+			abandoned = append(abandoned, r.View)
+		}
+	}
+	sort.SliceStable(abandoned, func(i, j int) bool { return abandoned[i].IsVIP() && !abandoned[j].IsVIP() })
+	return abandoned
+}
+
+// startPickupTimeout schedules a just-completed order to be abandoned if it
+// is not collected in time.
+// Must be called with lock held
+func (c *Controller) startPickupTimeout(o *order.Order) {
+	if c.pickupTimeout <= 0 {
+		return
+	}
+	c.pickupTimers[o.ID] = c.clock.AfterFunc(c.pickupTimeout, func() {
+		c.abandonOrder(o)
+	})
+}
+
+// abandonOrder marks an order ABANDONED when its pickup timeout fires
+func (c *Controller) abandonOrder(o *order.Order) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The order may have been collected after the timer fired but before we got the lock
+	if _, ok := c.pickupTimers[o.ID]; !ok || o.Status != order.COMPLETE {
+		return
+	}
+	delete(c.pickupTimers, o.ID)
+
+	now := c.clock.Now()
+	reason := fmt.Sprintf("not collected within %s", c.pickupTimeout)
+	if err := o.Transition(order.ABANDONED, now, 0, reason); err != nil {
+		c.reportError(now, err)
+		return
+	}
+
+	c.logger(fmt.Sprintf("[%s] Order #%d abandoned - %s - Status: %s", now.Format(c.timestampFormat), o.ID, reason, o.Status))
+	c.emit(Event{Type: EventOrderAbandoned, Time: now, OrderID: o.ID, OrderType: o.Type})
+	c.archiveOrder(o, now)
+}
+
+// archiveOrder moves an order awaiting pickup into the archive. Orders
+// already archived are left where they are. Either way the retention policy
+// is applied, so old orders leave even when nothing new is archived.
+// Must be called with lock held
+func (c *Controller) archiveOrder(o *order.Order, now time.Time) {
+	if indexOf(c.ready, o) < 0 {
+		c.reportExportError(now, c.archive.Prune(now))
+		return
+	}
+	c.ready = remove(c.ready, o)
+	c.reportExportError(now, c.archive.Add(o, now))
+}
+
+// reportExportError logs a failure to export orders evicted from the archive.
+// Must be called with lock held
+func (c *Controller) reportExportError(now time.Time, err error) {
+	if err != nil {
+		c.logger(fmt.Sprintf("[%s] Warning: %v", now.Format(c.timestampFormat), err))
+	}
+}
+
+// GetArchivedOrders returns a page of collected and abandoned orders matching
+// the query, oldest archived first. Orders past the retention policy are
+// evicted first.
+func (c *Controller) GetArchivedOrders(q archive.Query) archive.Page {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	c.reportExportError(now, c.archive.Prune(now))
+	return c.archive.Query(q)
+}
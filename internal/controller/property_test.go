@@ -0,0 +1,375 @@
+package controller
+
+import (
+	"assignment/internal/clock"
+	"assignment/internal/order"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The property tests drive a controller with random operations from several
+// goroutines at once and check the scheduling invariants along the way and
+// at the end. Run them with the race detector:
+//
+//	go test -race -run TestProperty ./internal/controller/
+//
+// A failing case is shrunk to a minimal operation sequence and reported with
+// the seed that produced it; pass it back with -seed to rerun that case first:
+//
+//	go test -run TestProperty ./internal/controller/ -seed 1700000000000000000
+
+var seedFlag = flag.Int64("seed", 0, "first seed of the property tests (0 picks one from the time)")
+
+// opKind is a random operation applied to the controller
+type opKind int
+
+const (
+	opNormal opKind = iota
+	opVIP
+	opAddBot
+	opRemoveBot
+	opAdvance
+	opRead
+	opMove
+	opTier
+)
+
+type op struct {
+	kind    opKind
+	advance time.Duration // for opAdvance
+	orderID int           // for opMove and opTier
+	up      bool          // for opMove, and promote rather than demote for opTier
+}
+
+func (o op) String() string {
+	switch o.kind {
+	case opNormal:
+		return "normal"
+	case opVIP:
+		return "vip"
+	case opAddBot:
+		return "+bot"
+	case opRemoveBot:
+		return "-bot"
+	case opAdvance:
+		return "advance " + o.advance.String()
+	case opMove:
+		if o.up {
+			return fmt.Sprintf("move #%d up", o.orderID)
+		}
+		return fmt.Sprintf("move #%d down", o.orderID)
+	case opTier:
+		if o.up {
+			return fmt.Sprintf("promote #%d", o.orderID)
+		}
+		return fmt.Sprintf("demote #%d", o.orderID)
+	default:
+		return "read"
+	}
+}
+
+// genOps returns n random operations, weighted towards order creation and time passing
+func genOps(rng *rand.Rand, n int) []op {
+	weights := []struct {
+		kind   opKind
+		weight int
+	}{{opNormal, 6}, {opVIP, 3}, {opAddBot, 2}, {opRemoveBot, 2}, {opAdvance, 5}, {opRead, 2}, {opMove, 2}, {opTier, 2}}
+	total := 0
+	for _, w := range weights {
+		total += w.weight
+	}
+
+	ops := make([]op, n)
+	for i := range ops {
+		pick := rng.Intn(total)
+		for _, w := range weights {
+			if pick < w.weight {
+				ops[i] = op{kind: w.kind}
+				break
+			}
+			pick -= w.weight
+		}
+		switch ops[i].kind {
+		case opAdvance:
+			ops[i].advance = time.Duration(rng.Intn(15000)) * time.Millisecond
+		case opMove, opTier:
+			ops[i].orderID = 1 + rng.Intn(n/2+1)
+			ops[i].up = rng.Intn(2) == 0
+		}
+	}
+	return ops
+}
+
+// runOps applies the operations, then drains all remaining work and checks the
+// end state. Time advances are applied in sequence order by the calling
+// goroutine; the controller calls between two advances are split round-robin
+// across the given number of goroutines and run concurrently.
+// It returns a description of every broken invariant.
+func runOps(ops []op, workers int) []string {
+	v := clock.NewVirtual(epoch)
+	// Tight limits keep orders moving through the waitlist; every one is
+	// admitted and cooked by the end of the drain
+	limits := QueueLimits{MaxVIP: 3, MaxNormal: 4, MaxTotal: 5, Waitlist: true, BusyThreshold: 80}
+	c := NewController(func(string) {}, WithClock(v), WithProcessingTime(10*time.Second), WithQueueLimits(limits),
+		WithInvariantChecks())
+
+	var mu sync.Mutex
+	problems := make([]string, 0)
+	c.Subscribe(func(e Event) {
+		if e.Type == EventInvariantViolated {
+			mu.Lock()
+			problems = append(problems, "violation: "+e.Detail)
+			mu.Unlock()
+		}
+	})
+
+	created := make([]int, workers)
+	for len(ops) > 0 {
+		// The segment runs up to the next advance, which only this goroutine applies
+		segment := ops
+		for i, o := range ops {
+			if o.kind == opAdvance {
+				segment = ops[:i]
+				break
+			}
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(segment); i += workers {
+					created[w] += apply(c, segment[i])
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		ops = ops[len(segment):]
+		if len(ops) > 0 {
+			v.Advance(ops[0].advance)
+			ops = ops[1:]
+		}
+	}
+
+	// Drain: make sure a bot is left and let every timer fire
+	if c.BotCount() == 0 {
+		c.AddBot()
+	}
+	for {
+		next, ok := v.Next()
+		if !ok {
+			break
+		}
+		v.AdvanceTo(next)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return append(problems, checkEndState(c, sum(created))...)
+}
+
+// apply performs a single controller operation other than a time advance and
+// returns the number of orders it created
+func apply(c *Controller, o op) int {
+	switch o.kind {
+	case opNormal:
+		if _, err := c.CreateNormalOrder(); err == nil {
+			return 1
+		}
+	case opVIP:
+		if _, err := c.CreateVIPOrder(); err == nil {
+			return 1
+		}
+	case opAddBot:
+		c.AddBot()
+	case opRemoveBot:
+		c.RemoveBot()
+	case opMove:
+		// Most moves fail: the order is gone, taken or at the end
+		if o.up {
+			c.MoveOrderUp(o.orderID)
+		} else {
+			c.MoveOrderDown(o.orderID)
+		}
+	case opTier:
+		if o.up {
+			c.PromoteOrder(o.orderID)
+		} else {
+			c.DemoteOrder(o.orderID)
+		}
+	case opRead:
+		// Snapshots must be safe to read while other goroutines mutate
+		orders, bots := c.GetState()
+		for _, o := range orders {
+			_ = o.Status
+		}
+		for _, b := range bots {
+			_ = b.OrderID
+		}
+		c.GetPendingOrders()
+		c.PendingCount()
+		c.BotCount()
+		c.OldestPendingWait()
+		c.GetBotStats()
+		c.GetWaitlistedOrders()
+		c.IsKitchenBusy()
+	}
+	return 0
+}
+
+// checkEndState verifies a drained controller
+func checkEndState(c *Controller, created int) []string {
+	problems := make([]string, 0)
+	// Uncollected orders are abandoned by the end of the drain
+	orders, bots := c.GetState()
+	orders = append(orders, c.GetAbandonedOrders()...)
+
+	if len(orders) != created {
+		problems = append(problems, fmt.Sprintf("expected %d orders, got %d", created, len(orders)))
+	}
+	seen := make(map[int]bool)
+	for _, o := range orders {
+		if seen[o.ID] {
+			problems = append(problems, fmt.Sprintf("order #%d appears twice", o.ID))
+		}
+		seen[o.ID] = true
+		if o.Status != order.COMPLETE && o.Status != order.ABANDONED {
+			problems = append(problems, fmt.Sprintf("order #%d left %s", o.ID, o.Status))
+		}
+	}
+	for id := 1; id <= created; id++ {
+		if !seen[id] {
+			problems = append(problems, fmt.Sprintf("order #%d missing", id))
+		}
+	}
+
+	for _, b := range bots {
+		if !b.IsIdle() || b.OrderID != 0 {
+			problems = append(problems, fmt.Sprintf("bot #%d still busy", b.ID))
+		}
+	}
+
+	completed := 0
+	for _, s := range c.GetBotStats() {
+		completed += s.OrdersCompleted
+	}
+	if completed != created {
+		problems = append(problems, fmt.Sprintf("bots completed %d orders, expected %d", completed, created))
+	}
+	return problems
+}
+
+func sum(ns []int) int {
+	total := 0
+	for _, n := range ns {
+		total += n
+	}
+	return total
+}
+
+// shrink removes operations from a failing sequence, largest chunks first,
+// and shortens time advances, as long as fails keeps returning true
+func shrink(ops []op, fails func([]op) bool) []op {
+	for chunk := len(ops) / 2; chunk >= 1; chunk /= 2 {
+		for start := 0; start+chunk <= len(ops); {
+			candidate := append(append([]op{}, ops[:start]...), ops[start+chunk:]...)
+			if fails(candidate) {
+				ops = candidate
+			} else {
+				start += chunk
+			}
+		}
+	}
+
+	for i := range ops {
+		for ops[i].kind == opAdvance && ops[i].advance > 0 {
+			candidate := append([]op{}, ops...)
+			candidate[i].advance /= 2
+			if !fails(candidate) {
+				break
+			}
+			ops = candidate
+		}
+	}
+	return ops
+}
+
+// checkProperty runs random sequences and reports the first failure, shrunk
+func checkProperty(t *testing.T, runs, length, workers int) {
+	t.Helper()
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("seed %d", seed)
+	for run := 0; run < runs; run++ {
+		rng := rand.New(rand.NewSource(seed + int64(run)))
+		ops := genOps(rng, length)
+		problems := runOps(ops, workers)
+		if len(problems) == 0 {
+			continue
+		}
+
+		// Concurrent runs are not reproducible, so shrink sequentially and
+		// fall back to the full sequence if that does not fail
+		minimal := ops
+		if len(runOps(ops, 1)) > 0 {
+			minimal = shrink(ops, func(candidate []op) bool { return len(runOps(candidate, 1)) > 0 })
+			problems = runOps(minimal, 1)
+		}
+
+		steps := make([]string, len(minimal))
+		for i, o := range minimal {
+			steps[i] = o.String()
+		}
+		t.Fatalf("seed %d: %d-step sequence broke invariants:\n  %s\nminimal sequence (%d steps): %s",
+			seed+int64(run), len(ops), strings.Join(problems, "\n  "), len(minimal), strings.Join(steps, ", "))
+	}
+}
+
+func TestPropertySequential(t *testing.T) {
+	runs := 200
+	if testing.Short() {
+		runs = 20
+	}
+	checkProperty(t, runs, 100, 1)
+}
+
+func TestPropertyConcurrent(t *testing.T) {
+	runs := 100
+	if testing.Short() {
+		runs = 10
+	}
+	checkProperty(t, runs, 200, 4)
+}
+
+func TestShrink(t *testing.T) {
+	// A sequence "fails" while it still holds a VIP order followed by a bot removal
+	fails := func(ops []op) bool {
+		vip := false
+		for _, o := range ops {
+			if o.kind == opVIP {
+				vip = true
+			}
+			if vip && o.kind == opRemoveBot {
+				return true
+			}
+		}
+		return false
+	}
+
+	ops := genOps(rand.New(rand.NewSource(1)), 200)
+	ops = append(ops, op{kind: opVIP}, op{kind: opRemoveBot})
+	minimal := shrink(ops, fails)
+
+	if len(minimal) != 2 || minimal[0].kind != opVIP || minimal[1].kind != opRemoveBot {
+		t.Errorf("Expected [vip -bot], got %v", minimal)
+	}
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"assignment/internal/archive"
+	"assignment/internal/order"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Position tells a customer where their order stands
+type Position struct {
+	Order order.View
+
+	// Place is the order's 1-based place among pending orders in the order
+	// bots will pick them up (VIP first); 0 if the order is not pending
+	Place int
+	Ahead int // pending orders that will be picked up first
+
+	// EstimatedWait is how long until a bot picks the order up and
+	// EstimatedReady how long until it is complete. They are only
+	// meaningful when Estimated is true: there is at least one bot.
+	EstimatedWait  time.Duration
+	EstimatedReady time.Duration
+	Estimated      bool
+}
+
+// GetOrderPosition returns an order's place in the queue and how long it is
+// expected to wait for a bot
+func (c *Controller) GetOrderPosition(id int) (Position, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o := c.findOrder(id)
+	if o == nil {
+		return Position{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+	p := Position{Order: o.View()}
+	if o.Status != order.PENDING {
+		return p, nil
+	}
+
+	starts := c.estimateStarts()
+	for i, queued := range c.pendingInOrder() {
+		if queued == o {
+			p.Place = i + 1
+			p.Ahead = i
+			if starts != nil {
+				p.EstimatedWait, p.EstimatedReady, p.Estimated = starts[i], starts[i]+c.processingTime, true
+			}
+			break
+		}
+	}
+	return p, nil
+}
+
+// ETA is when a pending order is expected to be complete
+type ETA struct {
+	Order   order.View
+	ReadyIn time.Duration
+	ReadyAt time.Time
+}
+
+// GetPendingETAs returns an ETA for every pending order, in the order bots
+// will pick them up. Estimates follow the current queue, bots and their
+// progress, so they change as soon as orders or bots are added or removed.
+// It returns nil when there are no bots.
+func (c *Controller) GetPendingETAs() []ETA {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	starts := c.estimateStarts()
+	if starts == nil {
+		return nil
+	}
+	now := c.clock.Now()
+	etas := make([]ETA, 0, len(starts))
+	for i, o := range c.pendingInOrder() {
+		ready := starts[i] + c.processingTime
+		etas = append(etas, ETA{Order: o.View(), ReadyIn: ready, ReadyAt: now.Add(ready)})
+	}
+	return etas
+}
+
+// pendingInOrder returns pending orders in the order bots pick them up.
+// Must be called with lock held
+func (c *Controller) pendingInOrder() []*order.Order {
+	pending := make([]*order.Order, 0)
+	for _, queue := range [][]*order.Order{c.vipOrders, c.normalOrders} {
+		for _, o := range queue {
+			if o.Status == order.PENDING {
+				pending = append(pending, o)
+			}
+		}
+	}
+	return pending
+}
+
+// estimateStarts returns, for each order of pendingInOrder, how long until a
+// bot is expected to pick it up: every bot finishes its current order, then
+// takes the next pending one. It returns nil when there are no bots.
+// Must be called with lock held
+func (c *Controller) estimateStarts() []time.Duration {
+	if len(c.bots) == 0 {
+		return nil
+	}
+	now := c.clock.Now()
+	free := make([]time.Duration, len(c.bots)) // when each bot is next free
+	for i, b := range c.bots {
+		if b.IsProcessing() {
+			if left := b.CurrentOrder.StartedAt.Add(c.processingTime).Sub(now); left > 0 {
+				free[i] = left
+			}
+		}
+	}
+
+	pending := c.pendingInOrder()
+	starts := make([]time.Duration, len(pending))
+	for i := range pending {
+		next := 0
+		for j := range free {
+			if free[j] < free[next] {
+				next = j
+			}
+		}
+		starts[i] = free[next]
+		free[next] += c.processingTime
+	}
+	return starts
+}
+
+// Query selects orders, live or archived. Zero values match everything.
+type Query struct {
+	Statuses    []order.OrderStatus // any of these statuses
+	Types       []order.OrderType   // any of these types
+	CreatedFrom time.Time           // created at or after
+	CreatedTo   time.Time           // created before
+	BotID       int                 // processed, at any point, by this bot
+	After       int                 // cursor: only orders with a higher ID (Page.Next)
+	Limit       int                 // page size; 0 returns every match
+}
+
+// Page is one page of query results in order ID order
+type Page struct {
+	Orders []order.View
+	Next   int // cursor for the following page, 0 when there are no more
+}
+
+// QueryOrders returns the orders matching q, including scheduled, waitlisted
+// and archived ones
+func (c *Controller) QueryOrders(q Query) Page {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matches := make([]order.View, 0)
+	for _, queue := range [][]*order.Order{c.vipOrders, c.normalOrders, c.ready, c.scheduled, c.waitlist} {
+		for _, o := range queue {
+			if v := o.View(); q.matches(v, o.History()) {
+				matches = append(matches, v)
+			}
+		}
+	}
+	for _, r := range c.archive.Query(archive.Query{}).Records {
+		if q.matches(r.View, r.History) {
+			matches = append(matches, r.View)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	page := Page{Orders: matches}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		page.Orders = matches[:q.Limit]
+		page.Next = matches[q.Limit-1].ID
+	}
+	return page
+}
+
+func (q Query) matches(v order.View, history []order.Transition) bool {
+	if v.ID <= q.After {
+		return false
+	}
+	if !q.CreatedFrom.IsZero() && v.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !v.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !containsStatus(q.Statuses, v.Status) {
+		return false
+	}
+	if len(q.Types) > 0 && !containsType(q.Types, v.Type) {
+		return false
+	}
+	if q.BotID == 0 {
+		return true
+	}
+	for _, t := range history {
+		if t.BotID == q.BotID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(statuses []order.OrderStatus, s order.OrderStatus) bool {
+	for _, status := range statuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(types []order.OrderType, t order.OrderType) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
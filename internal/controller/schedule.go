@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"assignment/internal/order"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScheduleOrder creates an order wanted at a later time, e.g. a pre-order or
+// catering order. It stays SCHEDULED, outside the queue, until the release
+// lead time before it is due, then joins the end of its tier's queue like a
+// new order. Orders due within the lead time are released straight away.
+// The due time must be after the current time.
+func (c *Controller) ScheduleOrder(orderType order.OrderType, due time.Time) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	if orderType != order.Normal && orderType != order.VIP {
+		return order.View{}, fmt.Errorf("%w: %d", ErrUnknownOrderType, orderType)
+	}
+	now := c.clock.Now()
+	if !due.After(now) {
+		return order.View{}, fmt.Errorf("%w: due at %s", ErrDueNotInFuture, due.Format(c.timestampFormat))
+	}
+
+	c.orderCounter++
+	o := order.NewScheduledOrderAt(c.orderCounter, orderType, now, due)
+	c.scheduled = append(c.scheduled, o)
+
+	detail := fmt.Sprintf("due at %s", due.Format(c.timestampFormat))
+	c.logger(fmt.Sprintf("[%s] %s Order #%d scheduled - %s - Status: %s", now.Format(c.timestampFormat), o.Type, o.ID, detail, o.Status))
+	c.emit(Event{Type: EventOrderScheduled, Time: now, OrderID: o.ID, OrderType: o.Type, Detail: detail})
+
+	if release := due.Add(-c.releaseLeadTime); release.After(now) {
+		c.releaseTimers[o.ID] = c.clock.AfterFunc(release.Sub(now), func() {
+			c.releaseScheduled(o)
+		})
+	} else {
+		c.release(o, now)
+	}
+	return o.View(), nil
+}
+
+// GetScheduledOrders returns orders not yet released into the queue, the
+// soonest due first
+func (c *Controller) GetScheduledOrders() []order.View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	scheduled := appendViews(make([]order.View, 0, len(c.scheduled)), c.scheduled, nil)
+	sort.SliceStable(scheduled, func(i, j int) bool { return scheduled[i].DueAt.Before(scheduled[j].DueAt) })
+	return scheduled
+}
+
+// releaseScheduled releases a scheduled order when its release timer fires
+func (c *Controller) releaseScheduled(o *order.Order) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Shutdown may have stopped the timer after it fired but before we got the lock
+	if _, ok := c.releaseTimers[o.ID]; !ok {
+		return
+	}
+	delete(c.releaseTimers, o.ID)
+	c.release(o, c.clock.Now())
+}
+
+// release moves a scheduled order to the end of its tier's queue and hands
+// it to an idle bot if there is one.
+// Must be called with lock held
+func (c *Controller) release(o *order.Order, now time.Time) {
+	reason := fmt.Sprintf("due at %s", o.DueAt.Format(c.timestampFormat))
+	if err := o.Transition(order.PENDING, now, 0, reason); err != nil {
+		c.reportError(now, err)
+		return
+	}
+	for i, s := range c.scheduled {
+		if s == o {
+			c.scheduled = append(c.scheduled[:i], c.scheduled[i+1:]...)
+			break
+		}
+	}
+	if o.IsVIP() {
+		c.vipOrders = append(c.vipOrders, o)
+	} else {
+		c.normalOrders = append(c.normalOrders, o)
+	}
+
+	c.logger(fmt.Sprintf("[%s] Order #%d released - %s - Status: %s", now.Format(c.timestampFormat), o.ID, reason, o.Status))
+	c.emit(Event{Type: EventOrderReleased, Time: now, OrderID: o.ID, OrderType: o.Type})
+
+	c.assignPendingOrders()
+}
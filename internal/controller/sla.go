@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"assignment/internal/clock"
+	"assignment/internal/order"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SLAStats counts how the controller has done against promised times
+type SLAStats struct {
+	Promised int // orders given a promised time
+	AtRisk   int // warnings that an order was expected to miss its promised time
+	Breached int // orders not complete by their promised time
+}
+
+// slaWatch tracks an order with a promised time until it completes or misses it
+type slaWatch struct {
+	order  *order.Order
+	timer  clock.Timer // fires at the promised time
+	start  clock.Timer // fires when the order must start to be ready in time, nil if already past
+	warned bool        // an at-risk event was raised for the current promise
+}
+
+// stop cancels the watch's timers
+func (w *slaWatch) stop() {
+	w.timer.Stop()
+	if w.start != nil {
+		w.start.Stop()
+	}
+}
+
+// SetPromisedBy promises an order will be ready by the given time, e.g. a
+// delivery partner's pickup slot. The controller then raises EventSLAAtRisk
+// as soon as the order is expected to miss it and EventSLABreached when it
+// does. A zero time withdraws the promise. It returns ErrOrderCooked for
+// orders that are already complete.
+func (c *Controller) SetPromisedBy(id int, by time.Time) (order.View, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutDown {
+		return order.View{}, ErrShutDown
+	}
+	o := c.findOrder(id)
+	if o == nil {
+		return order.View{}, fmt.Errorf("%w: #%d", ErrOrderNotFound, id)
+	}
+	if o.Status.IsCooked() {
+		return o.View(), fmt.Errorf("%w: #%d is %s", ErrOrderCooked, id, o.Status)
+	}
+
+	if w, ok := c.slaWatches[o.ID]; ok {
+		w.stop()
+		delete(c.slaWatches, o.ID)
+	}
+	o.PromisedBy = by
+	if by.IsZero() {
+		return o.View(), nil
+	}
+	c.slaStats.Promised++
+
+	now := c.clock.Now()
+	if !by.After(now) {
+		c.breachSLA(o, now)
+		return o.View(), nil
+	}
+	w := &slaWatch{
+		order: o,
+		timer: c.clock.AfterFunc(by.Sub(now), func() { c.promiseExpired(o) }),
+	}
+	if latest := by.Add(-c.processingTime); latest.After(now) {
+		w.start = c.clock.AfterFunc(latest.Sub(now), func() { c.latestStartPassed(o) })
+	}
+	c.slaWatches[o.ID] = w
+	c.checkSLAs()
+
+	return o.View(), nil
+}
+
+// GetSLAStats returns the promised, at-risk and breached order counts
+func (c *Controller) GetSLAStats() SLAStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.slaStats
+}
+
+// checkSLAs raises EventSLAAtRisk for every promised order now expected to be
+// ready after its promised time. Each promise is warned about at most once.
+// Must be called with lock held
+func (c *Controller) checkSLAs() {
+	if len(c.slaWatches) == 0 {
+		return
+	}
+	now := c.clock.Now()
+	ready := c.projectReady(now)
+
+	ids := make([]int, 0, len(c.slaWatches))
+	for id, w := range c.slaWatches {
+		if !w.warned {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		w := c.slaWatches[id]
+		o := w.order
+		if o.Status == order.SCHEDULED {
+			continue // checked once it is released
+		}
+		expected, ok := ready[o]
+		if ok && !expected.After(o.PromisedBy) {
+			continue
+		}
+
+		detail := fmt.Sprintf("promised by %s, no bot to cook it", o.PromisedBy.Format(c.timestampFormat))
+		if o.Status == order.HELD {
+			detail = fmt.Sprintf("promised by %s, on hold", o.PromisedBy.Format(c.timestampFormat))
+		} else if o.Status == order.WAITLISTED {
+			detail = fmt.Sprintf("promised by %s, on the waitlist", o.PromisedBy.Format(c.timestampFormat))
+		} else if ok {
+			detail = fmt.Sprintf("promised by %s, expected ready at %s", o.PromisedBy.Format(c.timestampFormat),
+				expected.Format(c.timestampFormat))
+		}
+		c.warnSLA(w, now, detail)
+	}
+}
+
+// warnSLA raises EventSLAAtRisk for a watched order.
+// Must be called with lock held
+func (c *Controller) warnSLA(w *slaWatch, now time.Time, detail string) {
+	w.warned = true
+	c.slaStats.AtRisk++
+	c.logger(fmt.Sprintf("[%s] SLA at risk: Order #%d %s", now.Format(c.timestampFormat), w.order.ID, detail))
+	c.emit(Event{Type: EventSLAAtRisk, Time: now, OrderID: w.order.ID, OrderType: w.order.Type, Detail: detail})
+}
+
+// latestStartPassed re-evaluates a promised order when a bot must start it
+// for it to be ready in time, so a queue that stops moving is noticed without
+// waiting for another event. The check runs after any bot finishing at the
+// same moment, which may still pick the order up in time.
+func (c *Controller) latestStartPassed(o *order.Order) {
+	c.clock.AfterFunc(0, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		w, ok := c.slaWatches[o.ID]
+		if !ok || w.warned || o.Status == order.PROCESSING || o.Status.IsCooked() {
+			return
+		}
+		c.checkSLAs()
+		if w.warned {
+			return
+		}
+		now := c.clock.Now()
+		c.warnSLA(w, now, fmt.Sprintf("promised by %s, not started by %s", o.PromisedBy.Format(c.timestampFormat),
+			o.PromisedBy.Add(-c.processingTime).Format(c.timestampFormat)))
+	})
+}
+
+// projectReady returns when each processing and pending order is expected to
+// be complete. Pending orders are left out when there are no bots.
+// Must be called with lock held
+func (c *Controller) projectReady(now time.Time) map[*order.Order]time.Time {
+	ready := make(map[*order.Order]time.Time)
+	for _, b := range c.bots {
+		if b.IsProcessing() {
+			ready[b.CurrentOrder] = b.CurrentOrder.StartedAt.Add(c.processingTime)
+		}
+	}
+	if starts := c.estimateStarts(); starts != nil {
+		for i, o := range c.pendingInOrder() {
+			ready[o] = now.Add(starts[i] + c.processingTime)
+		}
+	}
+	return ready
+}
+
+// promiseExpired raises EventSLABreached when an order's promised time
+// passes before it is complete. The check runs after any bot finishing at the
+// same moment, so an order ready exactly on time is not breached.
+func (c *Controller) promiseExpired(o *order.Order) {
+	c.clock.AfterFunc(0, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// The order may have completed after the timer fired but before we got the lock
+		if _, ok := c.slaWatches[o.ID]; !ok || o.Status.IsCooked() {
+			return
+		}
+		c.breachSLA(o, c.clock.Now())
+	})
+}
+
+// settleSLA stops watching a just-completed order, raising EventSLABreached
+// if it completed after its promised time.
+// Must be called with lock held
+func (c *Controller) settleSLA(o *order.Order) {
+	w, ok := c.slaWatches[o.ID]
+	if !ok {
+		return
+	}
+	w.stop()
+	delete(c.slaWatches, o.ID)
+	if o.CompletedAt.After(o.PromisedBy) {
+		c.breachSLA(o, o.CompletedAt)
+	}
+}
+
+// breachSLA records that an order missed its promised time.
+// Must be called with lock held
+func (c *Controller) breachSLA(o *order.Order, now time.Time) {
+	if w, ok := c.slaWatches[o.ID]; ok {
+		w.stop()
+		delete(c.slaWatches, o.ID)
+	}
+	c.slaStats.Breached++
+	detail := fmt.Sprintf("promised by %s, still %s", o.PromisedBy.Format(c.timestampFormat), o.Status)
+	if o.Status.IsCooked() {
+		detail = fmt.Sprintf("promised by %s, completed at %s", o.PromisedBy.Format(c.timestampFormat),
+			o.CompletedAt.Format(c.timestampFormat))
+	}
+	c.logger(fmt.Sprintf("[%s] SLA breached: Order #%d %s", now.Format(c.timestampFormat), o.ID, detail))
+	c.emit(Event{Type: EventSLABreached, Time: now, OrderID: o.ID, OrderType: o.Type, Detail: detail})
+}
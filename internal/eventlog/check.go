@@ -0,0 +1,41 @@
+package eventlog
+
+import (
+	"assignment/internal/controller"
+	"fmt"
+)
+
+// Issue is an inconsistency found in a log, with the line of the offending event
+type Issue struct {
+	Line int
+	controller.Violation
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// Check runs the controller's invariant checker over parsed log entries and
+// returns every issue found
+func Check(entries []Entry) []Issue {
+	checker := controller.NewInvariantChecker()
+	issues := make([]Issue, 0)
+	for _, e := range entries {
+		for _, v := range checker.Observe(e.Event) {
+			issues = append(issues, Issue{Line: e.Line, Violation: v})
+		}
+	}
+	return issues
+}
+
+// Skipped describes the checks Check could not run on entries because the log
+// does not record the events they need. Text result logs leave out bots being
+// added and removed, so removed bots are never checked there.
+func Skipped(entries []Entry) []string {
+	for _, e := range entries {
+		if e.Type == controller.EventBotAdded || e.Type == controller.EventBotRemoved {
+			return nil
+		}
+	}
+	return []string{"a removed bot returns its order to PENDING (the log records no bots added or removed)"}
+}
@@ -0,0 +1,74 @@
+package eventlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckConsistentLog(t *testing.T) {
+	entries, _ := Parse(strings.NewReader(sampleLog))
+	if issues := Check(entries); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestCheckReportsInconsistencies(t *testing.T) {
+	log := `[12:00:00] Normal Order #1 created - Status: PENDING
+[12:00:00] Normal Order #2 created - Status: PENDING
+[12:00:01] VIP Order #3 created - Status: PENDING
+[12:00:01] Normal Order #3 created - Status: PENDING
+[12:00:02] Bot #1 started processing Order #2
+[12:00:02] Bot #1 started processing Order #3
+[12:00:12] Order #2 completed by Bot #1 - Status: COMPLETE
+[12:00:13] Order #2 completed by Bot #1 - Status: COMPLETE
+[12:00:14] Bot #2 removed - Order #1 returned to PENDING
+`
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	issues := Check(entries)
+
+	want := []string{
+		"line 4: Order #3 created after Order #3; order IDs must increase",
+		"line 5: Normal Order #2 started while VIP Order #3 was pending",
+		"line 5: Normal Order #2 started before earlier Normal Order #1",
+		"line 6: Bot #1 started Order #3 while still processing Order #2",
+		"line 8: Order #2 completed twice",
+		"line 9: Order #1 returned to PENDING while PENDING",
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("Expected %d issues, got %d: %v", len(want), len(issues), issues)
+	}
+	for i, issue := range issues {
+		if issue.String() != want[i] {
+			t.Errorf("Issue %d: expected %q, got %q", i, want[i], issue.String())
+		}
+	}
+}
+
+func TestCheckerRemovedBotKeepsOrder(t *testing.T) {
+	log := `{"time":"2024-03-01T12:00:00Z","event":"order_created","order_id":1,"order_type":"Normal"}
+{"time":"2024-03-01T12:00:00Z","event":"order_started","order_id":1,"order_type":"Normal","bot_id":1,"wait_seconds":0}
+{"time":"2024-03-01T12:00:05Z","event":"bot_removed","bot_id":1}
+`
+	entries, _ := Parse(strings.NewReader(log))
+	issues := Check(entries)
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "without returning it to PENDING") {
+		t.Errorf("Expected removed bot issue, got %v", issues)
+	}
+}
+
+func TestSkippedWithoutBotEvents(t *testing.T) {
+	entries, _ := Parse(strings.NewReader(sampleLog))
+	if skipped := Skipped(entries); len(skipped) != 1 || !strings.Contains(skipped[0], "removed bot") {
+		t.Errorf("Expected the removed bot check to be skipped for a text log, got %v", skipped)
+	}
+
+	log := `{"time":"2024-03-01T12:00:00Z","event":"bot_added","bot_id":1}
+`
+	entries, _ = Parse(strings.NewReader(log))
+	if skipped := Skipped(entries); len(skipped) != 0 {
+		t.Errorf("Expected no skipped checks when bots are recorded, got %v", skipped)
+	}
+}
@@ -0,0 +1,319 @@
+package eventlog
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Patterns for the human-readable lines written by the controller
+var (
+	createdLine   = regexp.MustCompile(`^\[([^\]]+)\] (VIP|Normal) Order #(\d+) created`)
+	scheduledLine = regexp.MustCompile(`^\[([^\]]+)\] (VIP|Normal) Order #(\d+) scheduled - (due at .+?) - Status:`)
+	releasedLine  = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) released`)
+	waitlistLine  = regexp.MustCompile(`^\[([^\]]+)\] (VIP|Normal) Order #(\d+) waitlisted - (.+) - Status:`)
+	admittedLine  = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) admitted - Status:`)
+	heldLine      = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) (held|resumed) - Status:`)
+	movedLine     = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) moved (up|down) - (.+)$`)
+	retypedLine   = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) (promoted|demoted) to (VIP|Normal)$`)
+	startedLine   = regexp.MustCompile(`^\[([^\]]+)\] Bot #(\d+) started processing Order #(\d+)$`)
+	completedLine = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) completed by Bot #(\d+)`)
+	requeuedLine  = regexp.MustCompile(`^\[([^\]]+)\] Bot #(\d+) (?:removed|stopped) - Order #(\d+) returned to PENDING$`)
+	collectedLine = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) collected`)
+	abandonedLine = regexp.MustCompile(`^\[([^\]]+)\] Order #(\d+) abandoned`)
+	botAddedLine  = regexp.MustCompile(`^\[([^\]]+)\] Bot #(\d+) added$`)
+	botRemoveLine = regexp.MustCompile(`^\[([^\]]+)\] Bot #(\d+) removed$`)
+)
+
+// Entry is an event read back from a log, with the line it came from
+type Entry struct {
+	Line int
+	controller.Event
+}
+
+// Parse reads a result log in any of the formats written by the order
+// manager and returns the events it describes, in file order. The format is
+// detected per line: JSON objects, rows following a CSV header, and the
+// human-readable text lines. Other lines are skipped; malformed JSON or CSV
+// lines are reported with their line number.
+//
+// Text lines are expected to carry HH:MM:SS timestamps; use ParseLayout for
+// logs written with another timestamp_format.
+func Parse(r io.Reader) ([]Entry, error) {
+	return ParseLayout(r, DefaultLayout)
+}
+
+// DefaultLayout is the timestamp layout of text lines read by Parse
+const DefaultLayout = "15:04:05"
+
+// ParseLayout is Parse for text lines whose timestamps use the given Go time
+// layout. A text line naming an event whose timestamp does not match the
+// layout is reported with its line number.
+//
+// A layout without a date makes text event times fall on day zero; a clock
+// going backwards by more than 12 hours is then taken as passing midnight.
+func ParseLayout(r io.Reader, layout string) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	types := make(map[int]order.OrderType)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	inCSV := false
+	var day time.Duration
+	var last time.Time
+
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		var e controller.Event
+		var err error
+		switch {
+		case strings.HasPrefix(text, "{"):
+			e, err = parseJSONLine(text)
+		case text == strings.Join(csvHeader, ","):
+			inCSV = true
+			continue
+		case inCSV:
+			var row []string
+			row, err = csv.NewReader(strings.NewReader(text)).Read()
+			if err == nil {
+				e, err = parseCSVRow(row)
+			}
+		default:
+			var ok bool
+			if e, ok, err = parseLine(text, layout); !ok {
+				continue
+			}
+			if err != nil {
+				break
+			}
+			if e.Time.Year() == 0 {
+				if !last.IsZero() && last.Sub(e.Time.Add(day)) > 12*time.Hour {
+					day += 24 * time.Hour
+				}
+				e.Time = e.Time.Add(day)
+				last = e.Time
+			}
+
+			// Only the created, scheduled, waitlisted, promoted and demoted lines name the order type
+			if !namesType(e.Type) && e.OrderID != 0 {
+				e.OrderType = types[e.OrderID]
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("eventlog: line %d: %w", line, err)
+		}
+
+		if namesType(e.Type) {
+			types[e.OrderID] = e.OrderType
+		}
+		entries = append(entries, Entry{Line: line, Event: e})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("eventlog: %w", err)
+	}
+	return entries, nil
+}
+
+// namesType returns true for events that set an order's type
+func namesType(typ controller.EventType) bool {
+	switch typ {
+	case controller.EventOrderCreated, controller.EventOrderScheduled, controller.EventOrderWaitlisted,
+		controller.EventOrderPromoted, controller.EventOrderDemoted:
+		return true
+	}
+	return false
+}
+
+// parseLine reads a text line, returning false if it names no event and an
+// error if its timestamp does not match layout
+func parseLine(text, layout string) (controller.Event, bool, error) {
+	e, ok := matchLine(text)
+	if !ok {
+		return e, false, nil
+	}
+	stamp := text[1:strings.Index(text, "] ")]
+	t, err := time.Parse(layout, stamp)
+	if err != nil {
+		return e, true, fmt.Errorf("timestamp %q does not match layout %q", stamp, layout)
+	}
+	e.Time = t
+	return e, true, nil
+}
+
+// matchLine decodes every field of a text line but its timestamp
+func matchLine(text string) (controller.Event, bool) {
+	if m := createdLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderCreated, atoi(m[3]), 0, parseType(m[2])), true
+	}
+	if m := scheduledLine.FindStringSubmatch(text); m != nil {
+		e := event(controller.EventOrderScheduled, atoi(m[3]), 0, parseType(m[2]))
+		e.Detail = m[4]
+		return e, true
+	}
+	if m := releasedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderReleased, atoi(m[2]), 0, 0), true
+	}
+	if m := waitlistLine.FindStringSubmatch(text); m != nil {
+		e := event(controller.EventOrderWaitlisted, atoi(m[3]), 0, parseType(m[2]))
+		e.Detail = m[4]
+		return e, true
+	}
+	if m := admittedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderAdmitted, atoi(m[2]), 0, 0), true
+	}
+	if m := heldLine.FindStringSubmatch(text); m != nil {
+		typ := controller.EventOrderHeld
+		if m[3] == "resumed" {
+			typ = controller.EventOrderResumed
+		}
+		return event(typ, atoi(m[2]), 0, 0), true
+	}
+	if m := movedLine.FindStringSubmatch(text); m != nil {
+		typ := controller.EventOrderMovedUp
+		if m[3] == "down" {
+			typ = controller.EventOrderMovedDown
+		}
+		e := event(typ, atoi(m[2]), 0, 0)
+		e.Detail = m[4]
+		return e, true
+	}
+	if m := retypedLine.FindStringSubmatch(text); m != nil {
+		typ := controller.EventOrderPromoted
+		if m[3] == "demoted" {
+			typ = controller.EventOrderDemoted
+		}
+		return event(typ, atoi(m[2]), 0, parseType(m[4])), true
+	}
+	if m := startedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderStarted, atoi(m[3]), atoi(m[2]), 0), true
+	}
+	if m := completedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderCompleted, atoi(m[2]), atoi(m[3]), 0), true
+	}
+	if m := requeuedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderRequeued, atoi(m[3]), atoi(m[2]), 0), true
+	}
+	if m := collectedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderCollected, atoi(m[2]), 0, 0), true
+	}
+	if m := abandonedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventOrderAbandoned, atoi(m[2]), 0, 0), true
+	}
+	if m := botAddedLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventBotAdded, 0, atoi(m[2]), 0), true
+	}
+	if m := botRemoveLine.FindStringSubmatch(text); m != nil {
+		return event(controller.EventBotRemoved, 0, atoi(m[2]), 0), true
+	}
+	return controller.Event{}, false
+}
+
+func event(typ controller.EventType, orderID, botID int, orderType order.OrderType) controller.Event {
+	return controller.Event{Type: typ, OrderID: orderID, OrderType: orderType, BotID: botID}
+}
+
+func parseType(name string) order.OrderType {
+	if name == "VIP" {
+		return order.VIP
+	}
+	return order.Normal
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// Orders rebuilds the final state of every order created in the log, in ID order
+func Orders(entries []Entry) []order.View {
+	byID := make(map[int]*order.Order)
+	for _, e := range entries {
+		switch e.Type {
+		case controller.EventOrderCreated:
+			byID[e.OrderID] = order.NewOrderAt(e.OrderID, e.OrderType, e.Time)
+			continue
+		case controller.EventOrderScheduled:
+			// The due time is only kept as text in the event detail
+			byID[e.OrderID] = order.NewScheduledOrderAt(e.OrderID, e.OrderType, e.Time, time.Time{})
+			continue
+		case controller.EventOrderWaitlisted:
+			byID[e.OrderID] = order.NewWaitlistedOrderAt(e.OrderID, e.OrderType, e.Time)
+			continue
+		}
+		o, ok := byID[e.OrderID]
+		if !ok {
+			continue
+		}
+		// Transitions the log gets wrong are skipped here; Check reports them
+		switch e.Type {
+		case controller.EventOrderReleased:
+			o.Transition(order.PENDING, e.Time, 0, "released")
+		case controller.EventOrderAdmitted:
+			o.Transition(order.PENDING, e.Time, 0, "admitted from the waitlist")
+		case controller.EventOrderHeld:
+			o.Transition(order.HELD, e.Time, 0, "held by manager")
+		case controller.EventOrderResumed:
+			o.Transition(order.PENDING, e.Time, 0, "resumed by manager")
+		case controller.EventOrderMovedUp, controller.EventOrderMovedDown:
+			o.Note(e.Time, 0, "moved "+e.Detail)
+		case controller.EventOrderPromoted, controller.EventOrderDemoted:
+			o.ChangeType(e.OrderType, e.Time, "changed to "+e.OrderType.String())
+		case controller.EventOrderStarted:
+			o.Transition(order.PROCESSING, e.Time, e.BotID, "picked up")
+		case controller.EventOrderCompleted:
+			o.Transition(order.COMPLETE, e.Time, e.BotID, "cooked")
+		case controller.EventOrderRequeued:
+			o.Transition(order.PENDING, e.Time, e.BotID, "bot removed")
+		case controller.EventOrderCollected:
+			o.Transition(order.COLLECTED, e.Time, 0, "collected by customer")
+		case controller.EventOrderAbandoned:
+			o.Transition(order.ABANDONED, e.Time, 0, "not collected")
+		}
+	}
+
+	orders := make([]order.View, 0, len(byID))
+	for _, o := range byID {
+		orders = append(orders, o.View())
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+// Timeline is the sequence of events recorded for one order
+type Timeline struct {
+	OrderID   int
+	OrderType order.OrderType
+	Entries   []Entry
+}
+
+// Timelines groups order events by order, in order ID order
+func Timelines(entries []Entry) []Timeline {
+	byID := make(map[int]*Timeline)
+	for _, e := range entries {
+		if e.OrderID == 0 {
+			continue
+		}
+		t, ok := byID[e.OrderID]
+		if !ok {
+			t = &Timeline{OrderID: e.OrderID, OrderType: e.OrderType}
+			byID[e.OrderID] = t
+		}
+		t.Entries = append(t.Entries, e)
+	}
+
+	timelines := make([]Timeline, 0, len(byID))
+	for _, t := range byID {
+		timelines = append(timelines, *t)
+	}
+	sort.Slice(timelines, func(i, j int) bool { return timelines[i].OrderID < timelines[j].OrderID })
+	return timelines
+}
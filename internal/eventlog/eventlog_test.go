@@ -0,0 +1,269 @@
+package eventlog
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleLog = `[23:59:50] Normal Order #1 created - Status: PENDING
+[23:59:50] Normal Order #2 created - Status: PENDING
+[23:59:51] VIP Order #3 created - Status: PENDING
+some unrelated line
+[23:59:52] Bot #1 started processing Order #3
+[23:59:55] Bot #2 started processing Order #1
+[23:59:57] Bot #2 removed - Order #1 returned to PENDING
+[00:00:02] Order #3 completed by Bot #1 - Status: COMPLETE
+[00:00:02] Bot #1 started processing Order #1
+[00:00:12] Order #1 completed by Bot #1 - Status: COMPLETE
+`
+
+func TestParse(t *testing.T) {
+	entries, err := Parse(strings.NewReader(sampleLog))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(entries) != 9 {
+		t.Fatalf("Expected 9 events, got %d", len(entries))
+	}
+
+	requeue := entries[5]
+	if requeue.Type != controller.EventOrderRequeued || requeue.OrderID != 1 || requeue.BotID != 2 || requeue.Line != 7 {
+		t.Errorf("Unexpected requeue entry %+v", requeue)
+	}
+
+	completed := entries[6]
+	if completed.Type != controller.EventOrderCompleted || completed.OrderType != order.VIP {
+		t.Errorf("Expected completed VIP order, got %+v", completed)
+	}
+
+	// Passing midnight moves events to the next day
+	if gap := completed.Time.Sub(entries[5].Time); gap != 5*time.Second {
+		t.Errorf("Expected 5s between requeue and completion across midnight, got %v", gap)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	entries, _ := Parse(strings.NewReader(sampleLog))
+	orders := Orders(entries)
+
+	if len(orders) != 3 {
+		t.Fatalf("Expected 3 orders, got %d", len(orders))
+	}
+
+	first := orders[0]
+	if first.Status != order.COMPLETE {
+		t.Errorf("Expected order #1 complete, got %v", first.Status)
+	}
+	// Wait runs until the start that completed the order
+	if first.WaitDuration() != 12*time.Second || first.CookDuration() != 10*time.Second {
+		t.Errorf("Expected 12s wait and 10s cook, got %v and %v", first.WaitDuration(), first.CookDuration())
+	}
+
+	if orders[1].Status != order.PENDING {
+		t.Errorf("Expected order #2 still pending, got %v", orders[1].Status)
+	}
+}
+
+func TestTimelines(t *testing.T) {
+	entries, _ := Parse(strings.NewReader(sampleLog))
+	timelines := Timelines(entries)
+
+	if len(timelines) != 3 {
+		t.Fatalf("Expected 3 timelines, got %d", len(timelines))
+	}
+
+	first := timelines[0]
+	if first.OrderID != 1 || first.OrderType != order.Normal || len(first.Entries) != 5 {
+		t.Errorf("Unexpected timeline for order #1: %+v", first)
+	}
+	if first.Entries[2].Type != controller.EventOrderRequeued {
+		t.Errorf("Expected requeue as third event, got %v", first.Entries[2].Type)
+	}
+}
+
+func TestPickupEvents(t *testing.T) {
+	log := `[12:00:00] VIP Order #1 created - Status: PENDING
+[12:00:00] Normal Order #2 created - Status: PENDING
+[12:00:00] Bot #1 started processing Order #1
+[12:00:10] Order #1 completed by Bot #1 - Status: COMPLETE
+[12:00:10] Bot #1 started processing Order #2
+[12:00:20] Order #2 completed by Bot #1 - Status: COMPLETE
+[12:01:00] Order #1 collected - Status: COLLECTED
+[12:10:20] Order #2 abandoned - not collected within 10m0s - Status: ABANDONED
+`
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if issues := Check(entries); len(issues) != 0 {
+		t.Errorf("Expected a consistent log, got %v", issues)
+	}
+
+	orders := Orders(entries)
+	if orders[0].Status != order.COLLECTED || orders[1].Status != order.ABANDONED {
+		t.Errorf("Expected COLLECTED and ABANDONED, got %v and %v", orders[0].Status, orders[1].Status)
+	}
+	if last := entries[len(entries)-1]; last.OrderType != order.Normal {
+		t.Errorf("Expected the abandoned line to get its order type, got %v", last.OrderType)
+	}
+}
+
+func TestScheduledEvents(t *testing.T) {
+	log := `[08:00:00] VIP Order #1 scheduled - due at 12:00:00 - Status: SCHEDULED
+[08:00:05] Normal Order #2 created - Status: PENDING
+[11:45:00] Order #1 released - due at 12:00:00 - Status: PENDING
+[11:45:00] Bot #1 added
+[11:45:00] Bot #1 started processing Order #1
+[11:45:10] Order #1 completed by Bot #1 - Status: COMPLETE
+[11:45:10] Bot #1 started processing Order #2
+`
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if issues := Check(entries); len(issues) != 0 {
+		t.Errorf("Expected a consistent log, got %v", issues)
+	}
+	if e := entries[2]; e.Type != controller.EventOrderReleased || e.OrderType != order.VIP {
+		t.Errorf("Expected a VIP release, got %+v", e)
+	}
+
+	orders := Orders(entries)
+	if orders[0].Status != order.COMPLETE || orders[0].WaitDuration() != 0 {
+		t.Errorf("Expected Order #1 complete without waiting after release, got %+v", orders[0])
+	}
+
+	// A scheduled order cannot be cooked before it is released
+	early := `[08:00:00] Normal Order #1 scheduled - due at 12:00:00 - Status: SCHEDULED
+[08:00:01] Bot #1 started processing Order #1
+`
+	entries, _ = Parse(strings.NewReader(early))
+	if issues := Check(entries); len(issues) != 1 {
+		t.Errorf("Expected one issue, got %v", issues)
+	}
+}
+
+func TestManagerEvents(t *testing.T) {
+	log := `[12:00:00] Normal Order #1 created - Status: PENDING
+[12:00:00] Normal Order #2 created - Status: PENDING
+[12:00:01] Order #1 held - Status: HELD
+[12:00:02] Order #2 moved up - ahead of Order #1
+[12:00:03] Bot #1 added
+[12:00:03] Bot #1 started processing Order #2
+[12:00:04] Order #1 resumed - Status: PENDING
+`
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if issues := Check(entries); len(issues) != 0 {
+		t.Errorf("Expected a consistent log, got %v", issues)
+	}
+	if e := entries[3]; e.Type != controller.EventOrderMovedUp || e.Detail != "ahead of Order #1" {
+		t.Errorf("Expected a move up, got %+v", e)
+	}
+	if orders := Orders(entries); orders[0].Status != order.PENDING {
+		t.Errorf("Expected the resumed order PENDING, got %v", orders[0].Status)
+	}
+
+	// The hold alone lets Order #2 go first; without either it jumps the queue
+	if issues := Check(append(entries[:3:3], entries[4:]...)); len(issues) != 0 {
+		t.Errorf("Expected a held order to be skipped, got %v", issues)
+	}
+	if issues := Check(append(entries[:2:2], entries[4:6]...)); len(issues) != 1 {
+		t.Errorf("Expected one issue, got %v", issues)
+	}
+}
+
+func TestTypeChangeEvents(t *testing.T) {
+	log := `[12:00:00] VIP Order #1 created - Status: PENDING
+[12:00:00] Normal Order #2 created - Status: PENDING
+[12:00:01] Order #2 promoted to VIP
+[12:00:02] Order #1 demoted to Normal
+[12:00:03] Bot #1 added
+[12:00:03] Bot #1 started processing Order #2
+[12:00:13] Order #2 completed by Bot #1 - Status: COMPLETE
+[12:00:13] Bot #1 started processing Order #1
+`
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if issues := Check(entries); len(issues) != 0 {
+		t.Errorf("Expected a consistent log, got %v", issues)
+	}
+	if last := entries[len(entries)-1]; last.OrderType != order.Normal {
+		t.Errorf("Expected later lines to use the new type, got %v", last.OrderType)
+	}
+	if orders := Orders(entries); orders[0].Type != order.Normal || orders[1].Type != order.VIP {
+		t.Errorf("Expected the types swapped, got %v and %v", orders[0].Type, orders[1].Type)
+	}
+}
+
+func TestWaitlistEvents(t *testing.T) {
+	log := `[12:00:00] Normal Order #1 created - Status: PENDING
+[12:00:01] Normal Order #2 waitlisted - 1/1 Normal orders waiting - Status: WAITLISTED
+[12:00:02] Bot #1 added
+[12:00:02] Bot #1 started processing Order #1
+[12:00:02] Order #2 admitted - Status: PENDING
+[12:00:12] Order #1 completed by Bot #1 - Status: COMPLETE
+[12:00:12] Bot #1 started processing Order #2
+`
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if issues := Check(entries); len(issues) != 0 {
+		t.Errorf("Expected a consistent log, got %v", issues)
+	}
+	if e := entries[1]; e.Type != controller.EventOrderWaitlisted || e.Detail != "1/1 Normal orders waiting" {
+		t.Errorf("Expected a waitlisted event with its reason, got %+v", e)
+	}
+	if e := entries[4]; e.Type != controller.EventOrderAdmitted || e.OrderType != order.Normal {
+		t.Errorf("Expected a Normal admission, got %+v", e)
+	}
+
+	orders := Orders(entries)
+	if orders[1].Status != order.PROCESSING || orders[1].WaitDuration() != 10*time.Second {
+		t.Errorf("Expected Order #2 processing after waiting since it was admitted, got %+v", orders[1])
+	}
+
+	// A waitlisted order cannot be cooked before it is admitted
+	early := `[12:00:00] Normal Order #1 waitlisted - 2/2 orders waiting - Status: WAITLISTED
+[12:00:01] Bot #1 started processing Order #1
+`
+	entries, _ = Parse(strings.NewReader(early))
+	if issues := Check(entries); len(issues) != 1 {
+		t.Errorf("Expected one issue, got %v", issues)
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	log := `[2024-03-01 09:00:00] VIP Order #1 scheduled - due at 2024-03-01 12:00:00 - Status: SCHEDULED
+[2024-03-01 11:50:00] Order #1 released - Status: PENDING
+[2024-03-01 11:50:00] Bot #1 started processing Order #1
+[2024-03-01 11:50:10] Order #1 completed by Bot #1 - Status: COMPLETE
+`
+	entries, err := ParseLayout(strings.NewReader(log), "2006-01-02 15:04:05")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(entries))
+	}
+	if e := entries[0]; e.Detail != "due at 2024-03-01 12:00:00" || e.OrderType != order.VIP {
+		t.Errorf("Expected a scheduled VIP order with its due time, got %+v", e)
+	}
+	if want := time.Date(2024, 3, 1, 11, 50, 10, 0, time.UTC); !entries[3].Time.Equal(want) {
+		t.Errorf("Expected completion at %v, got %v", want, entries[3].Time)
+	}
+
+	// Timestamps written with another layout are an error, not skipped
+	if _, err := Parse(strings.NewReader(log)); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected a timestamp error on line 1, got %v", err)
+	}
+}
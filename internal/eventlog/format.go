@@ -0,0 +1,186 @@
+package eventlog
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Result log formats
+const (
+	FormatText = "text" // human-readable lines written by the controller logger
+	FormatJSON = "json" // one Record per line as a JSON object
+	FormatCSV  = "csv"  // a header row followed by one Record per row
+)
+
+// csvHeader is the first row of a CSV log
+var csvHeader = []string{"time", "event", "order_id", "order_type", "bot_id", "wait_seconds", "cook_seconds", "detail"}
+
+// Record is the structured form of an event in JSON and CSV logs.
+// Order fields are omitted on bot events and durations on events that have none.
+type Record struct {
+	Time        time.Time            `json:"time"`
+	Event       controller.EventType `json:"event"`
+	OrderID     int                  `json:"order_id,omitempty"`
+	OrderType   string               `json:"order_type,omitempty"`
+	BotID       int                  `json:"bot_id,omitempty"`
+	WaitSeconds *float64             `json:"wait_seconds,omitempty"`
+	CookSeconds *float64             `json:"cook_seconds,omitempty"`
+	Detail      string               `json:"detail,omitempty"`
+}
+
+// NewRecord converts a controller event to a record
+func NewRecord(e controller.Event) Record {
+	r := Record{Time: e.Time, Event: e.Type, OrderID: e.OrderID, BotID: e.BotID, Detail: e.Detail}
+	if e.OrderID != 0 {
+		r.OrderType = e.OrderType.String()
+	}
+	switch e.Type {
+	case controller.EventOrderStarted:
+		r.WaitSeconds = seconds(e.Wait)
+	case controller.EventOrderCompleted:
+		r.WaitSeconds = seconds(e.Wait)
+		r.CookSeconds = seconds(e.Cook)
+	}
+	return r
+}
+
+func seconds(d time.Duration) *float64 {
+	s := d.Seconds()
+	return &s
+}
+
+// ToEvent converts a record back to a controller event
+func (r Record) ToEvent() (controller.Event, error) {
+	e := controller.Event{Type: r.Event, Time: r.Time, OrderID: r.OrderID, BotID: r.BotID, Detail: r.Detail}
+	if r.OrderType != "" {
+		typ, ok := order.ParseType(r.OrderType)
+		if !ok {
+			return e, fmt.Errorf("unknown order type %q", r.OrderType)
+		}
+		e.OrderType = typ
+	}
+	if r.WaitSeconds != nil {
+		e.Wait = time.Duration(*r.WaitSeconds * float64(time.Second))
+	}
+	if r.CookSeconds != nil {
+		e.Cook = time.Duration(*r.CookSeconds * float64(time.Second))
+	}
+	return e, nil
+}
+
+// Writer writes controller events to a JSON-lines or CSV log
+type Writer struct {
+	format     string
+	w          io.Writer
+	csv        *csv.Writer
+	wroteFirst bool
+}
+
+// NewWriter returns a writer for FormatJSON or FormatCSV. Text logs are
+// written by the controller logger, not by a Writer.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &Writer{format: format, w: w}, nil
+	case FormatCSV:
+		return &Writer{format: format, w: w, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("eventlog: unsupported structured format %q, expected json or csv", format)
+	}
+}
+
+// Write appends one event to the log
+func (w *Writer) Write(e controller.Event) error {
+	r := NewRecord(e)
+	if w.format == FormatJSON {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.w.Write(append(line, '\n'))
+		return err
+	}
+
+	if !w.wroteFirst {
+		w.csv.Write(csvHeader)
+		w.wroteFirst = true
+	}
+	w.csv.Write(r.row())
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (r Record) row() []string {
+	row := []string{r.Time.Format(time.RFC3339Nano), string(r.Event), "", r.OrderType, "", "", "", r.Detail}
+	if r.OrderID != 0 {
+		row[2] = strconv.Itoa(r.OrderID)
+	}
+	if r.BotID != 0 {
+		row[4] = strconv.Itoa(r.BotID)
+	}
+	if r.WaitSeconds != nil {
+		row[5] = strconv.FormatFloat(*r.WaitSeconds, 'f', -1, 64)
+	}
+	if r.CookSeconds != nil {
+		row[6] = strconv.FormatFloat(*r.CookSeconds, 'f', -1, 64)
+	}
+	return row
+}
+
+// parseJSONLine reads one line of a JSON-lines log
+func parseJSONLine(text string) (controller.Event, error) {
+	var r Record
+	if err := json.Unmarshal([]byte(text), &r); err != nil {
+		return controller.Event{}, err
+	}
+	return r.ToEvent()
+}
+
+// parseCSVRow reads one data row of a CSV log
+func parseCSVRow(row []string) (controller.Event, error) {
+	if len(row) != len(csvHeader) {
+		return controller.Event{}, fmt.Errorf("expected %d columns, got %d", len(csvHeader), len(row))
+	}
+
+	var r Record
+	var err error
+	if r.Time, err = time.Parse(time.RFC3339Nano, row[0]); err != nil {
+		return controller.Event{}, fmt.Errorf("invalid time %q", row[0])
+	}
+	r.Event = controller.EventType(row[1])
+	r.OrderType = row[3]
+	r.Detail = row[7]
+	ints := []struct {
+		cell  string
+		field *int
+	}{{row[2], &r.OrderID}, {row[4], &r.BotID}}
+	for _, i := range ints {
+		if i.cell == "" {
+			continue
+		}
+		if *i.field, err = strconv.Atoi(i.cell); err != nil {
+			return controller.Event{}, fmt.Errorf("invalid integer %q", i.cell)
+		}
+	}
+	floats := []struct {
+		cell  string
+		field **float64
+	}{{row[5], &r.WaitSeconds}, {row[6], &r.CookSeconds}}
+	for _, f := range floats {
+		if f.cell == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(f.cell, 64)
+		if err != nil {
+			return controller.Event{}, fmt.Errorf("invalid number %q", f.cell)
+		}
+		*f.field = &v
+	}
+	return r.ToEvent()
+}
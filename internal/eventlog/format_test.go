@@ -0,0 +1,97 @@
+package eventlog
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sampleEvents = []controller.Event{
+	{Type: controller.EventBotAdded, Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), BotID: 1},
+	{Type: controller.EventOrderCreated, Time: time.Date(2024, 3, 1, 12, 0, 1, 0, time.UTC), OrderID: 1, OrderType: order.VIP},
+	{Type: controller.EventOrderStarted, Time: time.Date(2024, 3, 1, 12, 0, 1, 0, time.UTC), OrderID: 1, OrderType: order.VIP, BotID: 1},
+	{Type: controller.EventOrderCompleted, Time: time.Date(2024, 3, 1, 12, 0, 11, 0, time.UTC), OrderID: 1, OrderType: order.VIP, BotID: 1, Wait: 1500 * time.Millisecond, Cook: 10 * time.Second},
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, e := range sampleEvents {
+		if err := w.Write(e); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(sampleEvents) {
+		t.Fatalf("Expected %d lines, got %d", len(sampleEvents), len(lines))
+	}
+	if lines[0] != `{"time":"2024-03-01T12:00:00Z","event":"bot_added","bot_id":1}` {
+		t.Errorf("Unexpected bot line %s", lines[0])
+	}
+	want := `{"time":"2024-03-01T12:00:11Z","event":"order_completed","order_id":1,"order_type":"VIP","bot_id":1,"wait_seconds":1.5,"cook_seconds":10}`
+	if lines[3] != want {
+		t.Errorf("Expected %s, got %s", want, lines[3])
+	}
+
+	assertRoundTrip(t, buf.String())
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatCSV)
+	for _, e := range sampleEvents {
+		w.Write(e)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "time,event,order_id,order_type,bot_id,wait_seconds,cook_seconds,detail" {
+		t.Errorf("Unexpected header %s", lines[0])
+	}
+	if lines[1] != "2024-03-01T12:00:00Z,bot_added,,,1,,," {
+		t.Errorf("Unexpected bot row %s", lines[1])
+	}
+
+	assertRoundTrip(t, buf.String())
+}
+
+func assertRoundTrip(t *testing.T, log string) {
+	t.Helper()
+	entries, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != len(sampleEvents) {
+		t.Fatalf("Expected %d events, got %d", len(sampleEvents), len(entries))
+	}
+	for i, e := range entries {
+		if e.Event != sampleEvents[i] {
+			t.Errorf("Event %d: expected %+v, got %+v", i, sampleEvents[i], e.Event)
+		}
+	}
+}
+
+func TestParseRejectsMalformedStructuredLines(t *testing.T) {
+	logs := []string{
+		"{\"time\": \"yesterday\"}\n",
+		"{\"time\":\"2024-03-01T12:00:00Z\",\"event\":\"order_created\",\"order_id\":1,\"order_type\":\"Express\"}\n",
+		"time,event,order_id,order_type,bot_id,wait_seconds,cook_seconds,detail\n2024-03-01T12:00:00Z,bot_added,,,one,,,\n",
+	}
+	for _, log := range logs {
+		if _, err := Parse(strings.NewReader(log)); err == nil || !strings.Contains(err.Error(), "line") {
+			t.Errorf("Expected line error for %q, got %v", log, err)
+		}
+	}
+}
+
+func TestNewWriterRejectsText(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, FormatText); err == nil {
+		t.Error("Expected error for text format")
+	}
+}
@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"assignment/internal/bot"
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WaitBuckets are the upper bounds (in seconds) of the wait-time histogram
+var WaitBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600}
+
+// orderTypes fixes the label order in the output
+var orderTypes = []order.OrderType{order.Normal, order.VIP}
+
+// Source is the part of the controller read when rendering gauges
+type Source interface {
+	GetPendingOrders() []order.View
+	GetState() ([]order.View, []bot.View)
+}
+
+// histogram is a cumulative Prometheus-style histogram
+type histogram struct {
+	counts []uint64 // one per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, upper := range WaitBuckets {
+		if v <= upper {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Collector accumulates controller events into counters and histograms and
+// renders them in the Prometheus text exposition format
+type Collector struct {
+	mu         sync.Mutex
+	source     Source
+	created    map[order.OrderType]uint64
+	completed  map[order.OrderType]uint64
+	waitlisted map[order.OrderType]uint64
+	rejected   map[order.OrderType]uint64
+	requeues   uint64
+	promoted   uint64
+	demoted    uint64
+	collected  uint64
+	abandoned  uint64
+	slaAtRisk  uint64
+	slaBreach  uint64
+	violations uint64
+	waits      map[order.OrderType]*histogram
+}
+
+// NewCollector creates a collector subscribed to the controller's events
+func NewCollector(ctrl *controller.Controller) *Collector {
+	c := newCollector(ctrl)
+	ctrl.Subscribe(c.Observe)
+	return c
+}
+
+func newCollector(source Source) *Collector {
+	c := &Collector{
+		source:     source,
+		created:    make(map[order.OrderType]uint64),
+		completed:  make(map[order.OrderType]uint64),
+		waitlisted: make(map[order.OrderType]uint64),
+		rejected:   make(map[order.OrderType]uint64),
+		waits:      make(map[order.OrderType]*histogram),
+	}
+	for _, t := range orderTypes {
+		c.waits[t] = &histogram{counts: make([]uint64, len(WaitBuckets))}
+	}
+	return c
+}
+
+// Observe updates the counters for a single controller event
+func (c *Collector) Observe(e controller.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Type {
+	case controller.EventOrderCreated:
+		c.created[e.OrderType]++
+	case controller.EventOrderWaitlisted:
+		c.waitlisted[e.OrderType]++
+	case controller.EventOrderRejected:
+		c.rejected[e.OrderType]++
+	case controller.EventOrderCompleted:
+		c.completed[e.OrderType]++
+		c.waits[e.OrderType].observe(e.Wait.Seconds())
+	case controller.EventOrderRequeued:
+		c.requeues++
+	case controller.EventOrderPromoted:
+		c.promoted++
+	case controller.EventOrderDemoted:
+		c.demoted++
+	case controller.EventOrderCollected:
+		c.collected++
+	case controller.EventOrderAbandoned:
+		c.abandoned++
+	case controller.EventSLAAtRisk:
+		c.slaAtRisk++
+	case controller.EventSLABreached:
+		c.slaBreach++
+	case controller.EventInvariantViolated:
+		c.violations++
+	}
+}
+
+// ServeHTTP renders all metrics, making the collector usable as the /metrics handler
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	pending := make(map[order.OrderType]int)
+	for _, o := range c.source.GetPendingOrders() {
+		pending[o.Type]++
+	}
+	botsByStatus := make(map[bot.BotStatus]int)
+	_, bots := c.source.GetState()
+	for _, b := range bots {
+		botsByStatus[b.Status]++
+	}
+
+	c.mu.Lock()
+	header(&sb, "order_manager_orders_created_total", "counter", "Orders created, by order type when created.")
+	for _, t := range orderTypes {
+		fmt.Fprintf(&sb, "order_manager_orders_created_total{type=%q} %d\n", label(t), c.created[t])
+	}
+
+	header(&sb, "order_manager_orders_waitlisted_total", "counter", "Orders waitlisted because the queue was full, by order type.")
+	for _, t := range orderTypes {
+		fmt.Fprintf(&sb, "order_manager_orders_waitlisted_total{type=%q} %d\n", label(t), c.waitlisted[t])
+	}
+
+	header(&sb, "order_manager_orders_rejected_total", "counter", "Orders turned away because the queue was full, by order type.")
+	for _, t := range orderTypes {
+		fmt.Fprintf(&sb, "order_manager_orders_rejected_total{type=%q} %d\n", label(t), c.rejected[t])
+	}
+
+	header(&sb, "order_manager_orders_completed_total", "counter", "Orders completed, by order type when completed.")
+	for _, t := range orderTypes {
+		fmt.Fprintf(&sb, "order_manager_orders_completed_total{type=%q} %d\n", label(t), c.completed[t])
+	}
+
+	header(&sb, "order_manager_order_requeues_total", "counter", "Orders returned to PENDING because their bot was removed.")
+	fmt.Fprintf(&sb, "order_manager_order_requeues_total %d\n", c.requeues)
+
+	// Created and completed counts are by the type at the time, so VIP
+	// orders completed = created + promoted - demoted - still in flight
+	header(&sb, "order_manager_orders_promoted_total", "counter", "Normal orders changed to VIP.")
+	fmt.Fprintf(&sb, "order_manager_orders_promoted_total %d\n", c.promoted)
+
+	header(&sb, "order_manager_orders_demoted_total", "counter", "VIP orders changed to Normal.")
+	fmt.Fprintf(&sb, "order_manager_orders_demoted_total %d\n", c.demoted)
+
+	header(&sb, "order_manager_orders_collected_total", "counter", "Completed orders picked up by the customer.")
+	fmt.Fprintf(&sb, "order_manager_orders_collected_total %d\n", c.collected)
+
+	header(&sb, "order_manager_orders_abandoned_total", "counter", "Completed orders not picked up within the pickup timeout.")
+	fmt.Fprintf(&sb, "order_manager_orders_abandoned_total %d\n", c.abandoned)
+
+	header(&sb, "order_manager_sla_at_risk_total", "counter", "Warnings that an order was expected to miss its promised time.")
+	fmt.Fprintf(&sb, "order_manager_sla_at_risk_total %d\n", c.slaAtRisk)
+
+	header(&sb, "order_manager_sla_breached_total", "counter", "Orders not complete by their promised time.")
+	fmt.Fprintf(&sb, "order_manager_sla_breached_total %d\n", c.slaBreach)
+
+	header(&sb, "order_manager_invariant_violations_total", "counter", "Scheduling rule violations found by the invariant checker.")
+	fmt.Fprintf(&sb, "order_manager_invariant_violations_total %d\n", c.violations)
+
+	header(&sb, "order_manager_order_wait_seconds", "histogram", "Queue wait of completed orders, by order type.")
+	for _, t := range orderTypes {
+		h := c.waits[t]
+		var cumulative uint64
+		for i, upper := range WaitBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&sb, "order_manager_order_wait_seconds_bucket{type=%q,le=%q} %d\n", label(t), formatFloat(upper), cumulative)
+		}
+		fmt.Fprintf(&sb, "order_manager_order_wait_seconds_bucket{type=%q,le=\"+Inf\"} %d\n", label(t), h.count)
+		fmt.Fprintf(&sb, "order_manager_order_wait_seconds_sum{type=%q} %s\n", label(t), formatFloat(h.sum))
+		fmt.Fprintf(&sb, "order_manager_order_wait_seconds_count{type=%q} %d\n", label(t), h.count)
+	}
+	c.mu.Unlock()
+
+	header(&sb, "order_manager_pending_orders", "gauge", "Orders waiting for a bot, by order type.")
+	for _, t := range orderTypes {
+		fmt.Fprintf(&sb, "order_manager_pending_orders{type=%q} %d\n", label(t), pending[t])
+	}
+
+	header(&sb, "order_manager_bots", "gauge", "Bots currently in the system, by status.")
+	for _, s := range []bot.BotStatus{bot.IDLE, bot.PROCESSING} {
+		fmt.Fprintf(&sb, "order_manager_bots{status=%q} %d\n", strings.ToLower(s.String()), botsByStatus[s])
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func header(sb *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(sb, "# TYPE %s %s\n", name, kind)
+}
+
+func label(t order.OrderType) string {
+	return strings.ToLower(t.String())
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
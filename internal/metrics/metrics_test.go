@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, c *Collector) string {
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func expectLine(t *testing.T, body, line string) {
+	t.Helper()
+	for _, l := range strings.Split(body, "\n") {
+		if l == line {
+			return
+		}
+	}
+	t.Errorf("Expected line %q in output:\n%s", line, body)
+}
+
+func TestCountersAndGauges(t *testing.T) {
+	ctrl := controller.NewController(func(string) {})
+	c := NewCollector(ctrl)
+
+	ctrl.CreateNormalOrder()
+	ctrl.CreateNormalOrder()
+	ctrl.CreateVIPOrder()
+	c.Observe(controller.Event{Type: controller.EventOrderCollected, OrderID: 9, OrderType: order.VIP})
+	c.Observe(controller.Event{Type: controller.EventSLABreached, OrderID: 9, OrderType: order.VIP})
+	c.Observe(controller.Event{Type: controller.EventOrderRejected, OrderType: order.Normal})
+
+	body := scrape(t, c)
+
+	expectLine(t, body, "# TYPE order_manager_orders_created_total counter")
+	expectLine(t, body, `order_manager_orders_created_total{type="normal"} 2`)
+	expectLine(t, body, `order_manager_orders_created_total{type="vip"} 1`)
+	expectLine(t, body, `order_manager_pending_orders{type="normal"} 2`)
+	expectLine(t, body, `order_manager_pending_orders{type="vip"} 1`)
+	expectLine(t, body, `order_manager_bots{status="idle"} 0`)
+	expectLine(t, body, "order_manager_order_requeues_total 0")
+	expectLine(t, body, "order_manager_orders_collected_total 1")
+	expectLine(t, body, "order_manager_orders_abandoned_total 0")
+	expectLine(t, body, "order_manager_sla_at_risk_total 0")
+	expectLine(t, body, "order_manager_sla_breached_total 1")
+	expectLine(t, body, `order_manager_orders_rejected_total{type="normal"} 1`)
+	expectLine(t, body, `order_manager_orders_waitlisted_total{type="vip"} 0`)
+}
+
+func TestWaitHistogram(t *testing.T) {
+	ctrl := controller.NewController(func(string) {})
+	c := newCollector(ctrl)
+
+	c.Observe(controller.Event{Type: controller.EventOrderCompleted, OrderType: order.VIP, Wait: 3 * time.Second})
+	c.Observe(controller.Event{Type: controller.EventOrderCompleted, OrderType: order.VIP, Wait: 45 * time.Second})
+	c.Observe(controller.Event{Type: controller.EventOrderRequeued, OrderType: order.Normal})
+	c.Observe(controller.Event{Type: controller.EventInvariantViolated, Detail: "Order #1 completed twice"})
+
+	body := scrape(t, c)
+
+	expectLine(t, body, "# TYPE order_manager_order_wait_seconds histogram")
+	expectLine(t, body, `order_manager_order_wait_seconds_bucket{type="vip",le="1"} 0`)
+	expectLine(t, body, `order_manager_order_wait_seconds_bucket{type="vip",le="5"} 1`)
+	expectLine(t, body, `order_manager_order_wait_seconds_bucket{type="vip",le="60"} 2`)
+	expectLine(t, body, `order_manager_order_wait_seconds_bucket{type="vip",le="+Inf"} 2`)
+	expectLine(t, body, `order_manager_order_wait_seconds_sum{type="vip"} 48`)
+	expectLine(t, body, `order_manager_order_wait_seconds_count{type="vip"} 2`)
+	expectLine(t, body, `order_manager_orders_completed_total{type="vip"} 2`)
+	expectLine(t, body, "order_manager_order_requeues_total 1")
+	expectLine(t, body, "order_manager_invariant_violations_total 1")
+}
+
+func TestTypeChangesReconcile(t *testing.T) {
+	ctrl := controller.NewController(func(string) {})
+	c := NewCollector(ctrl)
+
+	first, _ := ctrl.CreateNormalOrder()
+	second, _ := ctrl.CreateVIPOrder()
+	ctrl.PromoteOrder(first.ID)
+	ctrl.DemoteOrder(second.ID)
+
+	body := scrape(t, c)
+
+	// Counts by type at creation plus the changes since match the queue
+	expectLine(t, body, `order_manager_orders_created_total{type="normal"} 1`)
+	expectLine(t, body, "order_manager_orders_promoted_total 1")
+	expectLine(t, body, "order_manager_orders_demoted_total 1")
+	expectLine(t, body, `order_manager_pending_orders{type="vip"} 1`)
+	expectLine(t, body, `order_manager_pending_orders{type="normal"} 1`)
+}
@@ -1,6 +1,10 @@
 package order
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // OrderType represents the type of order (Normal or VIP)
 type OrderType int
@@ -16,42 +20,264 @@ type OrderStatus int
 const (
 	PENDING OrderStatus = iota
 	PROCESSING
-	COMPLETE
+	COMPLETE   // cooked and waiting to be picked up
+	COLLECTED  // picked up by the customer
+	ABANDONED  // not picked up within the pickup timeout
+	SCHEDULED  // placed for a later time, not yet in the queue
+	HELD       // set aside by a manager; keeps its place but is not picked up
+	WAITLISTED // accepted while the queue was full, waiting for room in it
 )
 
+// IsCooked returns true once an order has been completed by a bot, whether
+// or not it has been picked up since
+func (os OrderStatus) IsCooked() bool {
+	return os == COMPLETE || os == COLLECTED || os == ABANDONED
+}
+
+// ErrInvalidTransition is returned when an order cannot move to the
+// requested status from its current one
+var ErrInvalidTransition = errors.New("invalid order state transition")
+
+// transitions lists the statuses each status may move to.
+// A cooked order never goes back to the kitchen, and an abandoned order can
+// still be collected if the customer turns up late.
+var transitions = map[OrderStatus][]OrderStatus{
+	SCHEDULED:  {PENDING},
+	WAITLISTED: {PENDING},
+	PENDING:    {PROCESSING, HELD},
+	HELD:       {PENDING},
+	PROCESSING: {COMPLETE, PENDING},
+	COMPLETE:   {COLLECTED, ABANDONED},
+	ABANDONED:  {COLLECTED},
+}
+
+// CanTransition returns true if an order may move from one status to another
+func CanTransition(from, to OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition is one entry in an order's history. Entries with the same From
+// and To record an action that did not change the status, such as a move
+// within the queue.
+type Transition struct {
+	From   OrderStatus
+	To     OrderStatus
+	At     time.Time
+	BotID  int // bot that made the change, 0 if none was involved
+	Reason string
+}
+
 // Order represents a customer order
 type Order struct {
 	ID          int
 	Type        OrderType
 	Status      OrderStatus
 	CreatedAt   time.Time
+	StartedAt   time.Time
 	CompletedAt time.Time
+	CollectedAt time.Time
+	PromisedBy  time.Time // when the order was promised ready, zero if no promise was made
+	DueAt       time.Time // when a scheduled order is wanted, zero for orders placed for now
+	ReleasedAt  time.Time // when a scheduled or waitlisted order entered the queue
+
+	history []Transition // append-only
 }
 
 // NewOrder creates a new order with the given ID and type
 func NewOrder(id int, orderType OrderType) *Order {
+	return NewOrderAt(id, orderType, time.Now())
+}
+
+// NewOrderAt creates a new order created at the given time
+func NewOrderAt(id int, orderType OrderType, createdAt time.Time) *Order {
 	return &Order{
 		ID:        id,
 		Type:      orderType,
 		Status:    PENDING,
-		CreatedAt: time.Now(),
+		CreatedAt: createdAt,
 	}
 }
 
-// SetProcessing updates the order status to PROCESSING
-func (o *Order) SetProcessing() {
-	o.Status = PROCESSING
+// NewScheduledOrderAt creates an order placed at createdAt for the given due
+// time. It stays SCHEDULED until it is released into the queue.
+func NewScheduledOrderAt(id int, orderType OrderType, createdAt, dueAt time.Time) *Order {
+	o := NewOrderAt(id, orderType, createdAt)
+	o.Status = SCHEDULED
+	o.DueAt = dueAt
+	return o
+}
+
+// NewWaitlistedOrderAt creates an order accepted at createdAt while the
+// queue was full. It stays WAITLISTED until there is room for it.
+func NewWaitlistedOrderAt(id int, orderType OrderType, createdAt time.Time) *Order {
+	o := NewOrderAt(id, orderType, createdAt)
+	o.Status = WAITLISTED
+	return o
+}
+
+// Transition moves the order to a new status and records the change in its
+// history. It returns ErrInvalidTransition if the move is not allowed from
+// the current status, leaving the order unchanged.
+// Returning to PENDING discards the interrupted start so waiting time keeps
+// accumulating; a scheduled or waitlisted order moving to PENDING starts
+// waiting.
+func (o *Order) Transition(to OrderStatus, at time.Time, botID int, reason string) error {
+	if !CanTransition(o.Status, to) {
+		return fmt.Errorf("%w: Order #%d cannot move from %s to %s", ErrInvalidTransition, o.ID, o.Status, to)
+	}
+	switch to {
+	case PROCESSING:
+		o.StartedAt = at
+	case COMPLETE:
+		o.CompletedAt = at
+	case COLLECTED:
+		o.CollectedAt = at
+	case PENDING:
+		if o.Status == SCHEDULED || o.Status == WAITLISTED {
+			o.ReleasedAt = at
+		}
+		o.StartedAt = time.Time{}
+	}
+	o.history = append(o.history, Transition{From: o.Status, To: to, At: at, BotID: botID, Reason: reason})
+	o.Status = to
+	return nil
+}
+
+// Note records an action on the order that does not change its status
+func (o *Order) Note(at time.Time, botID int, reason string) {
+	o.history = append(o.history, Transition{From: o.Status, To: o.Status, At: at, BotID: botID, Reason: reason})
+}
+
+// ChangeType moves the order to another tier and records the change in its history
+func (o *Order) ChangeType(to OrderType, at time.Time, reason string) {
+	o.Type = to
+	o.Note(at, 0, reason)
+}
+
+// History returns a copy of the order's recorded transitions, oldest first
+func (o *Order) History() []Transition {
+	return append([]Transition(nil), o.history...)
+}
+
+// SetProcessing updates the order status to PROCESSING and sets the start time
+func (o *Order) SetProcessing() error {
+	return o.SetProcessingAt(time.Now())
+}
+
+// SetProcessingAt updates the order status to PROCESSING, started at the given time
+func (o *Order) SetProcessingAt(at time.Time) error {
+	return o.Transition(PROCESSING, at, 0, "")
 }
 
 // SetComplete updates the order status to COMPLETE and sets the completion time
-func (o *Order) SetComplete() {
-	o.Status = COMPLETE
-	o.CompletedAt = time.Now()
+func (o *Order) SetComplete() error {
+	return o.SetCompleteAt(time.Now())
+}
+
+// SetCompleteAt updates the order status to COMPLETE, completed at the given time
+func (o *Order) SetCompleteAt(at time.Time) error {
+	return o.Transition(COMPLETE, at, 0, "")
+}
+
+// SetPending returns a PROCESSING order to PENDING status (used when bot is removed)
+func (o *Order) SetPending() error {
+	return o.Transition(PENDING, time.Now(), 0, "")
 }
 
-// SetPending returns the order to PENDING status (used when bot is removed)
-func (o *Order) SetPending() {
-	o.Status = PENDING
+// QueuedAt returns when the order entered the queue: its release or
+// admission for a scheduled or waitlisted order, otherwise its creation
+func (o *Order) QueuedAt() time.Time {
+	return queuedAt(o.CreatedAt, o.ReleasedAt)
+}
+
+// queuedAt is the queue entry time shared by Order and View
+func queuedAt(created, released time.Time) time.Time {
+	if !released.IsZero() {
+		return released
+	}
+	return created
+}
+
+// WaitDuration returns how long the order waited in the queue before the
+// bot that is processing (or completed) it picked it up.
+// Returns 0 if the order has not been started.
+func (o *Order) WaitDuration() time.Duration {
+	if o.StartedAt.IsZero() {
+		return 0
+	}
+	return o.StartedAt.Sub(o.QueuedAt())
+}
+
+// CookDuration returns how long the bot took to process the order.
+// Returns 0 if the order is not complete.
+func (o *Order) CookDuration() time.Duration {
+	if !o.Status.IsCooked() || o.StartedAt.IsZero() {
+		return 0
+	}
+	return o.CompletedAt.Sub(o.StartedAt)
+}
+
+// View is an immutable snapshot of an order
+type View struct {
+	ID          int
+	Type        OrderType
+	Status      OrderStatus
+	CreatedAt   time.Time
+	StartedAt   time.Time
+	CompletedAt time.Time
+	CollectedAt time.Time
+	PromisedBy  time.Time
+	DueAt       time.Time
+	ReleasedAt  time.Time
+}
+
+// View returns a snapshot of the order's current state
+func (o *Order) View() View {
+	return View{
+		ID:          o.ID,
+		Type:        o.Type,
+		Status:      o.Status,
+		CreatedAt:   o.CreatedAt,
+		StartedAt:   o.StartedAt,
+		CompletedAt: o.CompletedAt,
+		CollectedAt: o.CollectedAt,
+		PromisedBy:  o.PromisedBy,
+		DueAt:       o.DueAt,
+		ReleasedAt:  o.ReleasedAt,
+	}
+}
+
+// QueuedAt returns when the order had entered the queue, as Order.QueuedAt
+func (v View) QueuedAt() time.Time {
+	return queuedAt(v.CreatedAt, v.ReleasedAt)
+}
+
+// WaitDuration returns how long the order had waited in the queue before it
+// was picked up. Returns 0 if the order had not been started.
+func (v View) WaitDuration() time.Duration {
+	if v.StartedAt.IsZero() {
+		return 0
+	}
+	return v.StartedAt.Sub(v.QueuedAt())
+}
+
+// CookDuration returns how long the bot took to process the order.
+// Returns 0 if the order was not complete.
+func (v View) CookDuration() time.Duration {
+	if !v.Status.IsCooked() || v.StartedAt.IsZero() {
+		return 0
+	}
+	return v.CompletedAt.Sub(v.StartedAt)
+}
+
+// IsVIP returns true if the order is a VIP order
+func (v View) IsVIP() bool {
+	return v.Type == VIP
 }
 
 // IsVIP returns true if the order is a VIP order
@@ -80,7 +306,37 @@ func (os OrderStatus) String() string {
 		return "PROCESSING"
 	case COMPLETE:
 		return "COMPLETE"
+	case COLLECTED:
+		return "COLLECTED"
+	case ABANDONED:
+		return "ABANDONED"
+	case SCHEDULED:
+		return "SCHEDULED"
+	case HELD:
+		return "HELD"
+	case WAITLISTED:
+		return "WAITLISTED"
 	default:
 		return "Unknown"
 	}
 }
+
+// ParseStatus converts a status name such as "COMPLETE" back into an OrderStatus
+func ParseStatus(s string) (OrderStatus, bool) {
+	for _, status := range []OrderStatus{SCHEDULED, WAITLISTED, PENDING, HELD, PROCESSING, COMPLETE, COLLECTED, ABANDONED} {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// ParseType converts a type name such as "VIP" back into an OrderType
+func ParseType(s string) (OrderType, bool) {
+	for _, t := range []OrderType{Normal, VIP} {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
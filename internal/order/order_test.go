@@ -1,7 +1,9 @@
 package order
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestNewOrder(t *testing.T) {
@@ -33,6 +35,10 @@ func TestOrderStatusTransitions(t *testing.T) {
 		t.Errorf("Expected PROCESSING status, got %v", order.Status)
 	}
 	
+	if order.StartedAt.IsZero() {
+		t.Error("Expected StartedAt to be set")
+	}
+	
 	// Test PROCESSING -> COMPLETE
 	order.SetComplete()
 	if order.Status != COMPLETE {
@@ -43,10 +49,156 @@ func TestOrderStatusTransitions(t *testing.T) {
 		t.Error("Expected CompletedAt to be set")
 	}
 	
-	// Test returning to PENDING
-	order.SetPending()
-	if order.Status != PENDING {
-		t.Errorf("Expected PENDING status, got %v", order.Status)
+	// A COMPLETE order is final
+	if err := order.SetPending(); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+	if order.Status != COMPLETE {
+		t.Errorf("Expected COMPLETE status, got %v", order.Status)
+	}
+}
+
+func TestTransitionHistory(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	o := NewOrderAt(1, Normal, start)
+
+	steps := []Transition{
+		{From: PENDING, To: PROCESSING, At: start.Add(time.Second), BotID: 1, Reason: "picked up"},
+		{From: PROCESSING, To: PENDING, At: start.Add(2 * time.Second), BotID: 1, Reason: "bot removed"},
+		{From: PENDING, To: PROCESSING, At: start.Add(3 * time.Second), BotID: 2, Reason: "picked up"},
+		{From: PROCESSING, To: COMPLETE, At: start.Add(13 * time.Second), BotID: 2, Reason: "cooked"},
+	}
+	for _, step := range steps {
+		if err := o.Transition(step.To, step.At, step.BotID, step.Reason); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := o.Transition(PROCESSING, start.Add(time.Minute), 3, "picked up"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+
+	history := o.History()
+	if len(history) != len(steps) {
+		t.Fatalf("Expected %d transitions, got %d", len(steps), len(history))
+	}
+	for i := range steps {
+		if history[i] != steps[i] {
+			t.Errorf("Transition %d: expected %+v, got %+v", i, steps[i], history[i])
+		}
+	}
+
+	// The returned history is a copy
+	history[0].Reason = "changed"
+	if o.History()[0].Reason != "picked up" {
+		t.Error("Expected history to be unaffected by changes to the copy")
+	}
+}
+
+func TestNoteKeepsStatus(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	o := NewOrderAt(1, Normal, start)
+	o.Transition(HELD, start.Add(time.Second), 0, "held by manager")
+	o.Note(start.Add(2*time.Second), 0, "moved up")
+
+	want := Transition{From: HELD, To: HELD, At: start.Add(2 * time.Second), Reason: "moved up"}
+	if history := o.History(); len(history) != 2 || history[1] != want || o.Status != HELD {
+		t.Errorf("Expected a note without a status change, got %+v", history)
+	}
+
+	o.ChangeType(VIP, start.Add(3*time.Second), "promoted")
+	if history := o.History(); o.Type != VIP || len(history) != 3 || history[2].Reason != "promoted" {
+		t.Errorf("Expected the type change recorded, got %+v", history)
+	}
+}
+
+func TestCollectedOrderKeepsTimings(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	o := NewOrderAt(1, VIP, start)
+	o.Transition(PROCESSING, start.Add(2*time.Second), 1, "picked up")
+	o.Transition(COMPLETE, start.Add(12*time.Second), 1, "cooked")
+	o.Transition(ABANDONED, start.Add(time.Hour), 0, "not collected")
+
+	if err := o.Transition(COLLECTED, start.Add(2*time.Hour), 0, "collected"); err != nil {
+		t.Fatalf("Expected a late customer to collect an abandoned order, got %v", err)
+	}
+	if !o.CollectedAt.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("Expected CollectedAt to be set, got %v", o.CollectedAt)
+	}
+	if v := o.View(); v.CookDuration() != 10*time.Second || v.WaitDuration() != 2*time.Second {
+		t.Errorf("Expected timings to survive collection, got cook %v wait %v", v.CookDuration(), v.WaitDuration())
+	}
+}
+
+func TestScheduledOrderWaitsFromRelease(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)
+	o := NewScheduledOrderAt(1, Normal, start, start.Add(4*time.Hour))
+	if o.Status != SCHEDULED || !o.DueAt.Equal(start.Add(4*time.Hour)) {
+		t.Fatalf("Expected a SCHEDULED order due at noon, got %+v", o.View())
+	}
+
+	released := start.Add(3*time.Hour + 45*time.Minute)
+	if err := o.Transition(PENDING, released, 0, "released"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	o.Transition(PROCESSING, released.Add(20*time.Second), 1, "picked up")
+	if !o.ReleasedAt.Equal(released) || o.WaitDuration() != 20*time.Second || o.View().WaitDuration() != 20*time.Second {
+		t.Errorf("Expected the wait to start at release, got %v", o.WaitDuration())
+	}
+
+	// Returning to PENDING later keeps the release time
+	o.Transition(PENDING, released.Add(25*time.Second), 1, "bot removed")
+	if !o.ReleasedAt.Equal(released) {
+		t.Errorf("Expected ReleasedAt unchanged, got %v", o.ReleasedAt)
+	}
+}
+
+func TestWaitlistedOrderWaitsFromAdmission(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	o := NewWaitlistedOrderAt(1, Normal, start)
+
+	admitted := start.Add(5 * time.Minute)
+	if err := o.Transition(PENDING, admitted, 0, "admitted from the waitlist"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	o.Transition(PROCESSING, admitted.Add(20*time.Second), 1, "picked up")
+	if !o.ReleasedAt.Equal(admitted) || o.WaitDuration() != 20*time.Second || o.View().WaitDuration() != 20*time.Second {
+		t.Errorf("Expected the wait to start at admission, got %v", o.WaitDuration())
+	}
+	if !o.View().QueuedAt().Equal(o.QueuedAt()) || !o.QueuedAt().Equal(admitted) {
+		t.Errorf("Expected the view to agree on the queue entry time, got %v and %v", o.View().QueuedAt(), o.QueuedAt())
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		want     bool
+	}{
+		{PENDING, PROCESSING, true},
+		{PENDING, COMPLETE, false},
+		{PROCESSING, COMPLETE, true},
+		{PROCESSING, PENDING, true},
+		{COMPLETE, PENDING, false},
+		{COMPLETE, PROCESSING, false},
+		{COMPLETE, COLLECTED, true},
+		{COMPLETE, ABANDONED, true},
+		{ABANDONED, COLLECTED, true},
+		{COLLECTED, ABANDONED, false},
+		{COLLECTED, COMPLETE, false},
+		{SCHEDULED, PENDING, true},
+		{SCHEDULED, PROCESSING, false},
+		{PENDING, SCHEDULED, false},
+		{PENDING, HELD, true},
+		{HELD, PENDING, true},
+		{HELD, PROCESSING, false},
+		{PROCESSING, HELD, false},
+		{WAITLISTED, PENDING, true},
+		{WAITLISTED, PROCESSING, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
 	}
 }
 
@@ -85,3 +237,76 @@ func TestOrderStatusString(t *testing.T) {
 		t.Errorf("Expected 'COMPLETE', got '%s'", COMPLETE.String())
 	}
 }
+
+func TestDurations(t *testing.T) {
+	created := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	order := &Order{ID: 1, Type: Normal, Status: PENDING, CreatedAt: created}
+
+	if order.WaitDuration() != 0 || order.CookDuration() != 0 {
+		t.Error("Expected zero durations for a pending order")
+	}
+
+	order.Status = PROCESSING
+	order.StartedAt = created.Add(30 * time.Second)
+	if order.WaitDuration() != 30*time.Second {
+		t.Errorf("Expected 30s wait, got %v", order.WaitDuration())
+	}
+	if order.CookDuration() != 0 {
+		t.Errorf("Expected zero cook duration while processing, got %v", order.CookDuration())
+	}
+
+	order.Status = COMPLETE
+	order.CompletedAt = created.Add(40 * time.Second)
+	if order.CookDuration() != 10*time.Second {
+		t.Errorf("Expected 10s cook duration, got %v", order.CookDuration())
+	}
+
+	// An interrupted start does not count as waiting time being over
+	order.Status = PROCESSING
+	order.SetPending()
+	if !order.StartedAt.IsZero() {
+		t.Error("Expected StartedAt to be cleared when returned to PENDING")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for _, status := range []OrderStatus{SCHEDULED, WAITLISTED, PENDING, HELD, PROCESSING, COMPLETE, COLLECTED, ABANDONED} {
+		parsed, ok := ParseStatus(status.String())
+		if !ok || parsed != status {
+			t.Errorf("Expected %v to round-trip, got %v", status, parsed)
+		}
+	}
+
+	if _, ok := ParseStatus("DONE"); ok {
+		t.Error("Expected unknown status to be rejected")
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for _, typ := range []OrderType{Normal, VIP} {
+		parsed, ok := ParseType(typ.String())
+		if !ok || parsed != typ {
+			t.Errorf("Expected %v to round-trip, got %v", typ, parsed)
+		}
+	}
+
+	if _, ok := ParseType("Express"); ok {
+		t.Error("Expected unknown type to be rejected")
+	}
+}
+
+func TestView(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	o := NewOrderAt(1, VIP, start)
+	o.SetProcessingAt(start.Add(3 * time.Second))
+	view := o.View()
+	o.SetCompleteAt(start.Add(13 * time.Second))
+
+	// The snapshot does not follow later changes
+	if view.Status != PROCESSING || view.WaitDuration() != 3*time.Second || view.CookDuration() != 0 || !view.IsVIP() {
+		t.Errorf("Unexpected view %+v", view)
+	}
+	if after := o.View(); after.CookDuration() != 10*time.Second {
+		t.Errorf("Expected 10s cook time, got %v", after.CookDuration())
+	}
+}
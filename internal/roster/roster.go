@@ -0,0 +1,359 @@
+package roster
+
+import (
+	"assignment/internal/bot"
+	"assignment/internal/clock"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shift is a time-of-day window with a fixed number of bots.
+// Start is inclusive, End is exclusive. An End before Start is on the next
+// day, e.g. 22:00-06:00 for a night shift. Days restricts the shift to the
+// weekdays it starts on; an empty list means every day.
+type Shift struct {
+	Start int // minutes since midnight
+	End   int // minutes since midnight, on the next day if before Start
+	Bots  int
+	Days  []time.Weekday
+}
+
+// Roster is a weekly bot schedule. Outside of every shift DefaultBots applies.
+type Roster struct {
+	Shifts      []Shift
+	DefaultBots int
+}
+
+// fileShift and file mirror the on-disk JSON format, e.g.
+//
+//	{
+//	  "default_bots": 3,
+//	  "shifts": [
+//	    {"start": "07:00", "end": "11:00", "bots": 2},
+//	    {"start": "11:00", "end": "14:00", "bots": 6, "days": ["mon", "tue"]},
+//	    {"start": "22:00", "end": "06:00", "bots": 1}
+//	  ]
+//	}
+type fileShift struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Bots  int      `json:"bots"`
+	Days  []string `json:"days,omitempty"`
+}
+
+type file struct {
+	DefaultBots int         `json:"default_bots"`
+	Shifts      []fileShift `json:"shifts"`
+}
+
+// Load reads a roster from a JSON file
+func Load(path string) (*Roster, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("roster: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a roster from JSON
+func Parse(data []byte) (*Roster, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("roster: invalid JSON: %w", err)
+	}
+	if f.DefaultBots < 0 {
+		return nil, fmt.Errorf("roster: default_bots must not be negative, got %d", f.DefaultBots)
+	}
+
+	r := &Roster{DefaultBots: f.DefaultBots}
+	for i, fs := range f.Shifts {
+		s, err := parseShift(fs)
+		if err != nil {
+			return nil, fmt.Errorf("roster: shift %d: %w", i+1, err)
+		}
+		r.Shifts = append(r.Shifts, s)
+	}
+
+	// Shifts running on the same day must not overlap
+	for i := range r.Shifts {
+		for j := i + 1; j < len(r.Shifts); j++ {
+			if r.Shifts[i].overlaps(r.Shifts[j]) {
+				return nil, fmt.Errorf("roster: shift %d overlaps shift %d", i+1, j+1)
+			}
+		}
+	}
+	return r, nil
+}
+
+func parseShift(fs fileShift) (Shift, error) {
+	start, err := parseClock(fs.Start)
+	if err != nil {
+		return Shift{}, err
+	}
+	end, err := parseClock(fs.End)
+	if err != nil {
+		return Shift{}, err
+	}
+	if start == 24*60 {
+		return Shift{}, fmt.Errorf("start %s must be before 24:00", fs.Start)
+	}
+	if end == start {
+		return Shift{}, fmt.Errorf("end %s must differ from start %s", fs.End, fs.Start)
+	}
+	if fs.Bots < 0 {
+		return Shift{}, fmt.Errorf("bots must not be negative, got %d", fs.Bots)
+	}
+
+	s := Shift{Start: start, End: end, Bots: fs.Bots}
+	for _, d := range fs.Days {
+		wd, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return Shift{}, fmt.Errorf("unknown day %q", d)
+		}
+		s.Days = append(s.Days, wd)
+	}
+	return s, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseClock parses "HH:MM" into minutes since midnight. "24:00" is allowed as an end time.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// runsOn returns true if the shift applies on the given weekday
+func (s Shift) runsOn(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// length returns how many minutes the shift lasts
+func (s Shift) length() int {
+	if s.End < s.Start {
+		return s.End + 24*60 - s.Start
+	}
+	return s.End - s.Start
+}
+
+// weekMinutes is the length of the weekly cycle shifts repeat in
+const weekMinutes = 7 * 24 * 60
+
+func (s Shift) overlaps(other Shift) bool {
+	// Compare every pair of occurrences as minutes since Sunday midnight,
+	// also a week apart so Saturday night shifts meet Sunday morning ones
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if !s.runsOn(day) {
+			continue
+		}
+		start := int(day)*24*60 + s.Start
+		for otherDay := time.Sunday; otherDay <= time.Saturday; otherDay++ {
+			if !other.runsOn(otherDay) {
+				continue
+			}
+			for _, shift := range []int{-weekMinutes, 0, weekMinutes} {
+				otherStart := int(otherDay)*24*60 + other.Start + shift
+				if start < otherStart+other.length() && otherStart < start+s.length() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// At returns the number of bots scheduled at t and the time of the next
+// shift boundary after t, when the target may change. The boundary is zero
+// when the roster has no shifts and the target never changes.
+func (r *Roster) At(t time.Time) (int, time.Time) {
+	bots := r.DefaultBots
+	var next time.Time
+	// A shift started yesterday may still be running; within 8 days every
+	// shift starts at least once
+	for offset := -1; offset <= 7; offset++ {
+		midnight := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+		for _, s := range r.Shifts {
+			if !s.runsOn(midnight.Weekday()) {
+				continue
+			}
+			start := midnight.Add(time.Duration(s.Start) * time.Minute)
+			end := start.Add(time.Duration(s.length()) * time.Minute)
+			if !t.Before(start) && t.Before(end) {
+				bots = s.Bots
+			}
+			for _, edge := range []time.Time{start, end} {
+				if edge.After(t) && (next.IsZero() || edge.Before(next)) {
+					next = edge
+				}
+			}
+		}
+	}
+	return bots, next
+}
+
+// Target is the part of the controller the enforcer drives
+type Target interface {
+	BotCount() int
+	AddBot() (bot.View, error)
+	RemoveBot() (bot.View, error)
+}
+
+// Enforcer keeps the controller's bot count in line with a roster.
+// A manual change to the bot count in the middle of a shift is treated as an
+// override and left alone until the next shift boundary.
+type Enforcer struct {
+	mu       sync.Mutex
+	roster   *Roster
+	target   Target
+	logger   func(string)
+	clock    clock.Clock
+	layout   string    // timestamp layout of log lines
+	started  bool      // the roster target has been applied once
+	boundary time.Time // next shift boundary, zero if there is none
+	applied  int
+}
+
+// Option customises an enforcer created by NewEnforcer
+type Option func(*Enforcer)
+
+// WithClock times reconciliations with the given clock instead of the
+// system time. Pass the controller's clock so both follow the same time.
+func WithClock(clk clock.Clock) Option {
+	return func(e *Enforcer) {
+		e.clock = clk
+	}
+}
+
+// WithTimestampFormat sets the time layout of log lines, HH:MM:SS by
+// default. Pass the controller's layout so both logs line up.
+func WithTimestampFormat(layout string) Option {
+	return func(e *Enforcer) {
+		e.layout = layout
+	}
+}
+
+// NewEnforcer creates an enforcer for the given roster and target
+func NewEnforcer(r *Roster, target Target, logger func(string), opts ...Option) *Enforcer {
+	e := &Enforcer{
+		roster: r,
+		target: target,
+		logger: logger,
+		clock:  clock.Real(),
+		layout: "15:04:05",
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Run reconciles the bot count now and every interval until ctx is cancelled
+func (e *Enforcer) Run(ctx context.Context, interval time.Duration) {
+	stop := e.Start(interval)
+	defer stop()
+	<-ctx.Done()
+}
+
+// Start reconciles the bot count now and every interval, as measured by the
+// enforcer's clock, until the returned function is called. It does not
+// block: with a virtual clock, reconciliations run as the clock is advanced.
+func (e *Enforcer) Start(interval time.Duration) (stop func()) {
+	var mu sync.Mutex
+	stopped := false
+	var timer clock.Timer
+	var tick func()
+	tick = func() {
+		e.Reconcile(e.clock.Now())
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			timer = e.clock.AfterFunc(interval, tick)
+		}
+	}
+
+	e.Reconcile(e.clock.Now())
+	mu.Lock()
+	defer mu.Unlock()
+	timer = e.clock.AfterFunc(interval, tick)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		timer.Stop()
+	}
+}
+
+// Reconcile applies the roster target when a new shift starts, and otherwise
+// records manual overrides without undoing them
+func (e *Enforcer) Reconcile(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.started || (!e.boundary.IsZero() && !now.Before(e.boundary)) {
+		want, until := e.roster.At(now)
+		e.started = true
+		e.boundary = until
+		e.scaleTo(want)
+		e.applied = e.target.BotCount()
+		e.log(now, fmt.Sprintf("shift target %d bots %s", want, e.until()))
+		return
+	}
+
+	if current := e.target.BotCount(); current != e.applied {
+		e.applied = current
+		e.log(now, fmt.Sprintf("manual override to %d bots %s", current, e.until()))
+	}
+}
+
+// until describes how long the current target holds
+func (e *Enforcer) until() string {
+	if e.boundary.IsZero() {
+		return "until further notice"
+	}
+	return "until " + e.boundary.Format("Mon 15:04")
+}
+
+// scaleTo adds or removes bots using the controller's normal semantics
+// (removal always takes the newest bot)
+func (e *Enforcer) scaleTo(want int) {
+	for e.target.BotCount() < want {
+		if _, err := e.target.AddBot(); err != nil {
+			return
+		}
+	}
+	for e.target.BotCount() > want {
+		if _, err := e.target.RemoveBot(); err != nil {
+			return
+		}
+	}
+}
+
+func (e *Enforcer) log(now time.Time, msg string) {
+	if e.logger == nil {
+		return
+	}
+	e.logger(fmt.Sprintf("[%s] Roster %s", now.Format(e.layout), msg))
+}
@@ -0,0 +1,180 @@
+package roster
+
+import (
+	"assignment/internal/bot"
+	"assignment/internal/clock"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRoster = `{
+	"default_bots": 3,
+	"shifts": [
+		{"start": "07:00", "end": "11:00", "bots": 2},
+		{"start": "11:00", "end": "14:00", "bots": 6},
+		{"start": "18:00", "end": "21:00", "bots": 4, "days": ["sat"]},
+		{"start": "22:00", "end": "02:00", "bots": 1, "days": ["sat"]}
+	]
+}`
+
+type fakeTarget struct {
+	bots int
+}
+
+func (f *fakeTarget) BotCount() int { return f.bots }
+
+func (f *fakeTarget) AddBot() (bot.View, error) {
+	f.bots++
+	return bot.NewBot(f.bots).View(), nil
+}
+
+func (f *fakeTarget) RemoveBot() (bot.View, error) {
+	if f.bots == 0 {
+		return bot.View{}, errors.New("no bots available")
+	}
+	f.bots--
+	return bot.NewBot(f.bots + 1).View(), nil
+}
+
+// day returns a time on Friday 2024-03-01 at the given HH:MM
+func day(hour, minute int) time.Time {
+	return time.Date(2024, time.March, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func mustParse(t *testing.T) *Roster {
+	r, err := Parse([]byte(testRoster))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestAt(t *testing.T) {
+	r := mustParse(t)
+
+	tests := []struct {
+		at   time.Time
+		bots int
+		next time.Time
+	}{
+		{day(6, 30), 3, day(7, 0)},
+		{day(7, 0), 2, day(11, 0)},
+		{day(12, 15), 6, day(14, 0)},
+		// Midnight is not a boundary of its own
+		{day(14, 0), 3, day(7, 0).AddDate(0, 0, 1)},
+		// Saturday evening shift
+		{day(19, 0).AddDate(0, 0, 1), 4, day(21, 0).AddDate(0, 0, 1)},
+		// Friday has no evening shift
+		{day(19, 0), 3, day(7, 0).AddDate(0, 0, 1)},
+		// Saturday's night shift runs past midnight into Sunday
+		{day(23, 0).AddDate(0, 0, 1), 1, day(2, 0).AddDate(0, 0, 2)},
+		{day(1, 0).AddDate(0, 0, 2), 1, day(2, 0).AddDate(0, 0, 2)},
+		{day(2, 0).AddDate(0, 0, 2), 3, day(7, 0).AddDate(0, 0, 2)},
+	}
+
+	for _, tt := range tests {
+		bots, next := r.At(tt.at)
+		if bots != tt.bots {
+			t.Errorf("At(%s): expected %d bots, got %d", tt.at, tt.bots, bots)
+		}
+		if !next.Equal(tt.next) {
+			t.Errorf("At(%s): expected next boundary %s, got %s", tt.at, tt.next, next)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := []string{
+		`not json`,
+		`{"shifts": [{"start": "7am", "end": "11:00", "bots": 2}]}`,
+		`{"shifts": [{"start": "07:00", "end": "07:00", "bots": 2}]}`,
+		`{"shifts": [{"start": "07:00", "end": "11:00", "bots": -1}]}`,
+		`{"shifts": [{"start": "07:00", "end": "11:00", "bots": 2, "days": ["someday"]}]}`,
+		`{"shifts": [{"start": "07:00", "end": "12:00", "bots": 2}, {"start": "11:00", "end": "14:00", "bots": 6}]}`,
+		// A Saturday night shift running into a Sunday morning one
+		`{"shifts": [{"start": "22:00", "end": "06:00", "bots": 1, "days": ["sat"]}, {"start": "05:00", "end": "09:00", "bots": 2, "days": ["sun"]}]}`,
+	}
+	for _, data := range bad {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+}
+
+func TestEnforcerAppliesShift(t *testing.T) {
+	target := &fakeTarget{bots: 5}
+	logs := make([]string, 0)
+	e := NewEnforcer(mustParse(t), target, func(s string) { logs = append(logs, s) }, WithTimestampFormat("15:04"))
+
+	e.Reconcile(day(8, 0))
+	if target.bots != 2 {
+		t.Errorf("Expected 2 bots in morning shift, got %d", target.bots)
+	}
+
+	e.Reconcile(day(11, 0))
+	if target.bots != 6 {
+		t.Errorf("Expected 6 bots in lunch shift, got %d", target.bots)
+	}
+
+	if len(logs) != 2 || !strings.HasPrefix(logs[0], "[08:00] ") {
+		t.Errorf("Expected 2 log lines with the given layout, got %v", logs)
+	}
+}
+
+func TestManualOverrideExpiresAtBoundary(t *testing.T) {
+	target := &fakeTarget{}
+	logs := make([]string, 0)
+	e := NewEnforcer(mustParse(t), target, func(s string) { logs = append(logs, s) })
+
+	e.Reconcile(day(7, 30))
+
+	// Manager adds a bot during the morning shift
+	target.AddBot()
+	e.Reconcile(day(8, 0))
+	e.Reconcile(day(10, 59))
+	if target.bots != 3 {
+		t.Errorf("Expected manual override to be kept, got %d bots", target.bots)
+	}
+	if !strings.Contains(logs[len(logs)-1], "manual override") {
+		t.Errorf("Expected override to be logged, got %v", logs)
+	}
+
+	// Next shift boundary resets to the roster
+	e.Reconcile(day(11, 0))
+	if target.bots != 6 {
+		t.Errorf("Expected 6 bots after boundary, got %d", target.bots)
+	}
+}
+
+func TestOverrideKeptPastMidnight(t *testing.T) {
+	r, err := Parse([]byte(`{"default_bots": 1, "shifts": [{"start": "22:00", "end": "06:00", "bots": 2}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	v := clock.NewVirtual(day(21, 0))
+	target := &fakeTarget{}
+	e := NewEnforcer(r, target, nil, WithClock(v))
+
+	stop := e.Start(time.Minute)
+	defer stop()
+	if target.bots != 1 {
+		t.Errorf("Expected the default 1 bot before the night shift, got %d", target.bots)
+	}
+	v.Advance(time.Hour)
+	if target.bots != 2 {
+		t.Errorf("Expected 2 bots in the night shift, got %d", target.bots)
+	}
+
+	// A manual override holds through midnight until the shift ends
+	target.AddBot()
+	v.Advance(8*time.Hour - time.Minute)
+	if target.bots != 3 {
+		t.Errorf("Expected the override kept until 06:00, got %d bots", target.bots)
+	}
+	v.Advance(time.Minute)
+	if target.bots != 1 {
+		t.Errorf("Expected the default 1 bot after the night shift, got %d", target.bots)
+	}
+}
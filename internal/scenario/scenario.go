@@ -0,0 +1,283 @@
+package scenario
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActionKind is a controller operation performed by a scenario step
+type ActionKind int
+
+const (
+	NormalOrder ActionKind = iota
+	VIPOrder
+	AddBot
+	RemoveBot
+)
+
+// String returns the scenario keyword for the action
+func (k ActionKind) String() string {
+	switch k {
+	case NormalOrder:
+		return "normal"
+	case VIPOrder:
+		return "vip"
+	case AddBot:
+		return "+bot"
+	case RemoveBot:
+		return "-bot"
+	default:
+		return "unknown"
+	}
+}
+
+var actionKinds = map[string]ActionKind{
+	"normal": NormalOrder,
+	"vip":    VIPOrder,
+	"+bot":   AddBot,
+	"-bot":   RemoveBot,
+}
+
+// Step performs an action Count times at offset At from the start of the run
+type Step struct {
+	Line   int
+	At     time.Duration
+	Action ActionKind
+	Count  int
+}
+
+// Expectation requires an order to reach a status no later than By
+type Expectation struct {
+	Line    int
+	OrderID int
+	Status  order.OrderStatus
+	By      time.Duration
+}
+
+// String returns the expectation in scenario syntax
+func (e Expectation) String() string {
+	return fmt.Sprintf("expect order %d %s by %s", e.OrderID, e.Status, e.By)
+}
+
+// Scenario is a parsed scenario file
+type Scenario struct {
+	Steps        []Step
+	Expectations []Expectation
+}
+
+// Load reads a scenario file
+func Load(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("scenario: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a scenario, one instruction per line:
+//
+//	# comments and blank lines are ignored
+//	at 0s vip
+//	at 2s normal x3
+//	at 5s +bot
+//	at 12s -bot
+//	expect order 3 COMPLETE by 25s
+func Parse(r io.Reader) (*Scenario, error) {
+	s := &Scenario{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		var err error
+		switch fields[0] {
+		case "at":
+			err = s.parseStep(line, fields)
+		case "expect":
+			err = s.parseExpectation(line, fields)
+		default:
+			err = fmt.Errorf("unknown instruction %q", fields[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("scenario: line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scenario: %w", err)
+	}
+
+	// Steps at the same offset keep their file order
+	sort.SliceStable(s.Steps, func(i, j int) bool { return s.Steps[i].At < s.Steps[j].At })
+	return s, nil
+}
+
+// parseStep parses "at <offset> <action> [xN]"
+func (s *Scenario) parseStep(line int, fields []string) error {
+	if len(fields) != 3 && len(fields) != 4 {
+		return fmt.Errorf("expected \"at <offset> <action> [xN]\"")
+	}
+	at, err := parseOffset(fields[1])
+	if err != nil {
+		return err
+	}
+	kind, ok := actionKinds[fields[2]]
+	if !ok {
+		return fmt.Errorf("unknown action %q", fields[2])
+	}
+	count := 1
+	if len(fields) == 4 {
+		n, err := strconv.Atoi(strings.TrimPrefix(fields[3], "x"))
+		if err != nil || !strings.HasPrefix(fields[3], "x") || n < 1 {
+			return fmt.Errorf("invalid repeat %q, expected xN", fields[3])
+		}
+		count = n
+	}
+	s.Steps = append(s.Steps, Step{Line: line, At: at, Action: kind, Count: count})
+	return nil
+}
+
+// parseExpectation parses "expect order <id> <STATUS> by <offset>"
+func (s *Scenario) parseExpectation(line int, fields []string) error {
+	if len(fields) != 6 || fields[1] != "order" || fields[4] != "by" {
+		return fmt.Errorf("expected \"expect order <id> <STATUS> by <offset>\"")
+	}
+	id, err := strconv.Atoi(fields[2])
+	if err != nil || id < 1 {
+		return fmt.Errorf("invalid order id %q", fields[2])
+	}
+	status, ok := order.ParseStatus(fields[3])
+	if !ok {
+		return fmt.Errorf("unknown status %q", fields[3])
+	}
+	by, err := parseOffset(fields[5])
+	if err != nil {
+		return err
+	}
+	s.Expectations = append(s.Expectations, Expectation{Line: line, OrderID: id, Status: status, By: by})
+	return nil
+}
+
+func parseOffset(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	return d, nil
+}
+
+// Result lists the expectations that were not met
+type Result struct {
+	Failures []string
+}
+
+// Passed returns true if every expectation was met
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// pollInterval is how often expectations are checked while running
+const pollInterval = 50 * time.Millisecond
+
+// Run executes the scenario against the controller in real time. It returns
+// once every step has run and every expectation has either been met or passed its deadline.
+func (s *Scenario) Run(ctrl *controller.Controller) Result {
+	// Invariant violations, if the controller checks them, fail the scenario
+	var violationsMu sync.Mutex
+	violations := make([]string, 0)
+	ctrl.Subscribe(func(e controller.Event) {
+		if e.Type == controller.EventInvariantViolated {
+			violationsMu.Lock()
+			violations = append(violations, "invariant violated: "+e.Detail)
+			violationsMu.Unlock()
+		}
+	})
+
+	start := time.Now()
+	created := make(map[int]bool)
+	pending := make([]Expectation, len(s.Expectations))
+	copy(pending, s.Expectations)
+	result := Result{}
+	next := 0
+
+	for {
+		elapsed := time.Since(start)
+
+		for next < len(s.Steps) && s.Steps[next].At <= elapsed {
+			for _, o := range s.apply(ctrl, s.Steps[next]) {
+				created[o.ID] = true
+			}
+			next++
+		}
+
+		remaining := pending[:0]
+		for _, e := range pending {
+			o, err := ctrl.GetOrder(e.OrderID)
+			ok := err == nil && created[e.OrderID]
+			if ok && o.Status == e.Status {
+				continue
+			}
+			if elapsed > e.By {
+				status := "not created"
+				if ok {
+					status = o.Status.String()
+				}
+				result.Failures = append(result.Failures,
+					fmt.Sprintf("line %d: %s: order is %s", e.Line, e, status))
+				continue
+			}
+			remaining = append(remaining, e)
+		}
+		pending = remaining
+
+		if next == len(s.Steps) && len(pending) == 0 {
+			violationsMu.Lock()
+			defer violationsMu.Unlock()
+			result.Failures = append(result.Failures, violations...)
+			return result
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// apply performs a step and returns any orders it created. Rejected
+// actions, such as removing a bot when there are none, are skipped; the
+// expectations show their effect.
+func (s *Scenario) apply(ctrl *controller.Controller, step Step) []order.View {
+	created := make([]order.View, 0)
+	for i := 0; i < step.Count; i++ {
+		var o order.View
+		var err error
+		switch step.Action {
+		case NormalOrder:
+			o, err = ctrl.CreateNormalOrder()
+		case VIPOrder:
+			o, err = ctrl.CreateVIPOrder()
+		case AddBot:
+			ctrl.AddBot()
+			continue
+		case RemoveBot:
+			ctrl.RemoveBot()
+			continue
+		}
+		if err == nil {
+			created = append(created, o)
+		}
+	}
+	return created
+}
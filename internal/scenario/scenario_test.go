@@ -0,0 +1,98 @@
+package scenario
+
+import (
+	"assignment/internal/controller"
+	"assignment/internal/order"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	input := `
+# rush hour
+at 2s normal x3
+at 0s vip
+at 5s +bot
+at 12s -bot
+expect order 3 COMPLETE by 25s
+`
+	s, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(s.Steps) != 4 {
+		t.Fatalf("Expected 4 steps, got %d", len(s.Steps))
+	}
+
+	// Steps are sorted by offset
+	if s.Steps[0].Action != VIPOrder || s.Steps[0].At != 0 {
+		t.Errorf("Expected vip at 0s first, got %+v", s.Steps[0])
+	}
+	if s.Steps[1].Action != NormalOrder || s.Steps[1].Count != 3 {
+		t.Errorf("Expected normal x3 second, got %+v", s.Steps[1])
+	}
+
+	if len(s.Expectations) != 1 {
+		t.Fatalf("Expected 1 expectation, got %d", len(s.Expectations))
+	}
+	e := s.Expectations[0]
+	if e.OrderID != 3 || e.Status != order.COMPLETE || e.By != 25*time.Second || e.Line != 7 {
+		t.Errorf("Unexpected expectation %+v", e)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := []string{
+		"at soon vip",
+		"at 1s pizza",
+		"at 1s normal 3",
+		"at 1s normal x0",
+		"expect order x COMPLETE by 1s",
+		"expect order 1 DONE by 1s",
+		"expect order 1 COMPLETE at 1s",
+		"wait 5s",
+	}
+	for _, input := range bad {
+		_, err := Parse(strings.NewReader(input))
+		if err == nil {
+			t.Errorf("Expected error for %q", input)
+			continue
+		}
+		if !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("Expected line number in error, got %v", err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	input := `
+at 0s vip
+at 0s normal
+at 200ms +bot
+expect order 1 PROCESSING by 1s
+expect order 2 PENDING by 1s
+expect order 2 COMPLETE by 500ms
+expect order 9 PENDING by 500ms
+`
+	s, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result := s.Run(controller.NewController(func(string) {}))
+
+	if result.Passed() {
+		t.Fatal("Expected unmet expectations to fail the run")
+	}
+	if len(result.Failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %v", result.Failures)
+	}
+	if !strings.Contains(result.Failures[0], "order is PENDING") {
+		t.Errorf("Expected failure to report the actual status, got %q", result.Failures[0])
+	}
+	if !strings.Contains(result.Failures[1], "not created") {
+		t.Errorf("Expected failure for missing order, got %q", result.Failures[1])
+	}
+}
@@ -0,0 +1,177 @@
+package simulator
+
+import (
+	"assignment/internal/bot"
+	"assignment/internal/clock"
+	"assignment/internal/controller"
+	"assignment/internal/eventlog"
+	"assignment/internal/order"
+	"assignment/internal/stats"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// DefaultStart is the virtual start time used when Config.Start is zero.
+// A fixed start keeps the event log identical between runs.
+var DefaultStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Config describes a simulated trading period
+type Config struct {
+	Seed           int64
+	Start          time.Time
+	Duration       time.Duration // how long orders keep arriving
+	OrdersPerHour  float64       // mean of the Poisson arrival process
+	VIPRatio       float64       // fraction of orders that are VIP, 0..1
+	Bots           int
+	ProcessingTime time.Duration
+}
+
+// DefaultConfig returns a one-day simulation at 300 orders/hour with 20% VIP
+func DefaultConfig() Config {
+	return Config{
+		Seed:           1,
+		Duration:       24 * time.Hour,
+		OrdersPerHour:  300,
+		VIPRatio:       0.2,
+		Bots:           1,
+		ProcessingTime: bot.ProcessingTime,
+	}
+}
+
+// Validate checks that the configuration describes a runnable simulation
+func (c Config) Validate() error {
+	if c.Duration <= 0 {
+		return fmt.Errorf("simulator: duration must be positive, got %s", c.Duration)
+	}
+	if c.OrdersPerHour <= 0 {
+		return fmt.Errorf("simulator: orders per hour must be positive, got %g", c.OrdersPerHour)
+	}
+	if c.VIPRatio < 0 || c.VIPRatio > 1 {
+		return fmt.Errorf("simulator: VIP ratio must be between 0 and 1, got %g", c.VIPRatio)
+	}
+	if c.Bots < 1 {
+		return fmt.Errorf("simulator: at least one bot is required, got %d", c.Bots)
+	}
+	if c.ProcessingTime <= 0 {
+		return fmt.Errorf("simulator: processing time must be positive, got %s", c.ProcessingTime)
+	}
+	return nil
+}
+
+// Result is the outcome of a simulation
+type Result struct {
+	Log []string // controller log lines, in virtual-time order
+
+	Orders         int
+	VIPOrders      int
+	MaxPending     int           // deepest pending queue observed
+	BacklogAtClose int           // orders still pending or processing when arrivals stopped
+	DrainedAt      time.Time     // when the last order completed
+	Report         stats.Report  // wait/cook statistics over the whole run
+	Bots           []bot.Stats   // measured up to DrainedAt
+	Elapsed        time.Duration // simulated time from start to DrainedAt
+}
+
+// Run simulates the configured period with a virtual clock. The same
+// configuration always produces the same result.
+func Run(cfg Config) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+	start := cfg.Start
+	if start.IsZero() {
+		start = DefaultStart
+	}
+
+	result := Result{}
+	v := clock.NewVirtual(start)
+	ctrl := controller.NewController(func(msg string) {
+		result.Log = append(result.Log, msg)
+	}, controller.WithClock(v), controller.WithProcessingTime(cfg.ProcessingTime))
+
+	// Uncollected orders leave the controller once their pickup timeout
+	// passes, so the statistics are rebuilt from the events instead
+	events := make([]eventlog.Entry, 0)
+	completed := 0
+	ctrl.Subscribe(func(e controller.Event) {
+		events = append(events, eventlog.Entry{Event: e})
+		if e.Type == controller.EventOrderCompleted {
+			completed++
+		}
+	})
+
+	for i := 0; i < cfg.Bots; i++ {
+		ctrl.AddBot()
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	meanGap := float64(time.Hour) / cfg.OrdersPerHour
+	closing := start.Add(cfg.Duration)
+	at := start
+
+	for {
+		// Exponential inter-arrival times give a Poisson arrival process
+		at = at.Add(time.Duration(rng.ExpFloat64() * meanGap))
+		if !at.Before(closing) {
+			break
+		}
+		v.AdvanceTo(at)
+
+		if rng.Float64() < cfg.VIPRatio {
+			ctrl.CreateVIPOrder()
+			result.VIPOrders++
+		} else {
+			ctrl.CreateNormalOrder()
+		}
+		result.Orders++
+
+		if pending := ctrl.PendingCount(); pending > result.MaxPending {
+			result.MaxPending = pending
+		}
+	}
+
+	v.AdvanceTo(closing)
+	result.BacklogAtClose = len(ctrl.GetPendingOrders())
+	_, bots := ctrl.GetState()
+	for _, b := range bots {
+		if b.IsProcessing() {
+			result.BacklogAtClose++
+		}
+	}
+
+	// Let the bots finish whatever is left
+	for completed < result.Orders {
+		next, ok := v.Next()
+		if !ok {
+			break
+		}
+		v.AdvanceTo(next)
+	}
+
+	result.DrainedAt = v.Now()
+	result.Elapsed = result.DrainedAt.Sub(start)
+	result.Report = stats.Build(eventlog.Orders(events), result.DrainedAt)
+	result.Bots = ctrl.GetBotStats()
+	return result, nil
+}
+
+// Summary returns a human-readable summary of the result
+func (r Result) Summary() []string {
+	lines := []string{
+		fmt.Sprintf("Orders: %d (VIP %d, Normal %d)", r.Orders, r.VIPOrders, r.Orders-r.VIPOrders),
+		fmt.Sprintf("Max pending: %d", r.MaxPending),
+		fmt.Sprintf("Backlog at close: %d", r.BacklogAtClose),
+		fmt.Sprintf("Last order completed after: %s", r.Elapsed.Round(time.Second)),
+		fmt.Sprintf("Throughput: %.2f orders/min", r.Report.ThroughputPerMinute),
+	}
+	for _, t := range []order.OrderType{order.VIP, order.Normal} {
+		d := r.Report.Wait[t]
+		lines = append(lines, fmt.Sprintf("%s wait: n=%d mean=%s p50=%s p90=%s p99=%s", t, d.Count,
+			d.Mean.Round(time.Second), d.P50.Round(time.Second), d.P90.Round(time.Second), d.P99.Round(time.Second)))
+	}
+	for _, b := range r.Bots {
+		lines = append(lines, fmt.Sprintf("Bot #%d: completed %d, utilisation %.1f%%", b.ID, b.OrdersCompleted, b.Utilisation()*100))
+	}
+	return lines
+}
@@ -0,0 +1,106 @@
+package simulator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Duration = 2 * time.Hour
+	cfg.Bots = 2
+	return cfg
+}
+
+func TestDeterministic(t *testing.T) {
+	first, err := Run(testConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := Run(testConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(first.Log, second.Log) {
+		t.Error("Expected identical event logs for the same seed")
+	}
+	if !reflect.DeepEqual(first.Summary(), second.Summary()) {
+		t.Error("Expected identical summaries for the same seed")
+	}
+
+	cfg := testConfig()
+	cfg.Seed = 2
+	third, _ := Run(cfg)
+	if reflect.DeepEqual(first.Log, third.Log) {
+		t.Error("Expected a different seed to produce a different log")
+	}
+}
+
+func TestAllOrdersComplete(t *testing.T) {
+	result, err := Run(testConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 300 orders/hour for 2 hours
+	if result.Orders < 500 || result.Orders > 700 {
+		t.Errorf("Expected roughly 600 orders, got %d", result.Orders)
+	}
+	if result.VIPOrders == 0 || result.VIPOrders > result.Orders/2 {
+		t.Errorf("Expected roughly 20%% VIP orders, got %d of %d", result.VIPOrders, result.Orders)
+	}
+	if result.Report.Completed != result.Orders {
+		t.Errorf("Expected all %d orders completed, got %d", result.Orders, result.Report.Completed)
+	}
+
+	completed := 0
+	for _, b := range result.Bots {
+		completed += b.OrdersCompleted
+	}
+	if completed != result.Orders {
+		t.Errorf("Expected bots to complete %d orders, got %d", result.Orders, completed)
+	}
+
+	if !strings.HasPrefix(result.Log[0], "[00:00:00] Bot #1 added") {
+		t.Errorf("Expected log to use virtual time, got %q", result.Log[0])
+	}
+}
+
+func TestMoreBotsReduceWait(t *testing.T) {
+	cfg := testConfig()
+	cfg.Bots = 1
+	oneBot, _ := Run(cfg)
+
+	cfg.Bots = 3
+	threeBots, _ := Run(cfg)
+
+	if threeBots.Report.AllWait.P90 >= oneBot.Report.AllWait.P90 {
+		t.Errorf("Expected 3 bots to cut p90 wait, got %v vs %v",
+			threeBots.Report.AllWait.P90, oneBot.Report.AllWait.P90)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.VIPRatio = 1.5
+	if _, err := Run(cfg); err == nil {
+		t.Error("Expected error for VIP ratio above 1")
+	}
+}
+
+// BenchmarkBusyDay simulates a full day at a busy store's rate. Scheduling
+// cost must not grow with the orders already cooked, or this takes minutes.
+func BenchmarkBusyDay(b *testing.B) {
+	cfg := DefaultConfig()
+	cfg.Duration = 24 * time.Hour
+	cfg.OrdersPerHour = 2000
+	cfg.Bots = 6
+	for i := 0; i < b.N; i++ {
+		if _, err := Run(cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
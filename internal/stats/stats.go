@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"assignment/internal/order"
+	"math"
+	"sort"
+	"time"
+)
+
+// Distribution summarises a set of durations
+type Distribution struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// Summarize computes mean and percentiles for the given durations
+func Summarize(durations []time.Duration) Distribution {
+	if len(durations) == 0 {
+		return Distribution{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return Distribution{
+		Count: len(sorted),
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   Percentile(sorted, 50),
+		P90:   Percentile(sorted, 90),
+		P99:   Percentile(sorted, 99),
+	}
+}
+
+// Percentile returns the p-th percentile (nearest-rank) of sorted durations
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Report holds wait and cook time statistics for a set of orders.
+// Only completed orders are included in the distributions.
+type Report struct {
+	Wait                map[order.OrderType]Distribution
+	AllWait             Distribution
+	Cook                Distribution
+	Completed           int
+	ThroughputPerMinute float64
+}
+
+// Build computes a report for the given orders. Throughput is measured from
+// the creation of the first order until now.
+func Build(orders []order.View, now time.Time) Report {
+	waitsByType := make(map[order.OrderType][]time.Duration)
+	allWaits := make([]time.Duration, 0)
+	cooks := make([]time.Duration, 0)
+	var first time.Time
+
+	for _, o := range orders {
+		if first.IsZero() || o.CreatedAt.Before(first) {
+			first = o.CreatedAt
+		}
+		if !o.Status.IsCooked() {
+			continue
+		}
+		waitsByType[o.Type] = append(waitsByType[o.Type], o.WaitDuration())
+		allWaits = append(allWaits, o.WaitDuration())
+		cooks = append(cooks, o.CookDuration())
+	}
+
+	r := Report{
+		Wait:      make(map[order.OrderType]Distribution),
+		AllWait:   Summarize(allWaits),
+		Cook:      Summarize(cooks),
+		Completed: len(allWaits),
+	}
+	for t, waits := range waitsByType {
+		r.Wait[t] = Summarize(waits)
+	}
+	if elapsed := now.Sub(first); !first.IsZero() && elapsed > 0 {
+		r.ThroughputPerMinute = float64(r.Completed) / elapsed.Minutes()
+	}
+	return r
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"assignment/internal/order"
+	"testing"
+	"time"
+)
+
+func seconds(values ...int) []time.Duration {
+	ds := make([]time.Duration, len(values))
+	for i, v := range values {
+		ds[i] = time.Duration(v) * time.Second
+	}
+	return ds
+}
+
+func TestSummarize(t *testing.T) {
+	d := Summarize(seconds(10, 1, 9, 2, 8, 3, 7, 4, 6, 5))
+
+	if d.Count != 10 {
+		t.Errorf("Expected count 10, got %d", d.Count)
+	}
+	if d.Mean != 5500*time.Millisecond {
+		t.Errorf("Expected mean 5.5s, got %v", d.Mean)
+	}
+	if d.P50 != 5*time.Second {
+		t.Errorf("Expected p50 5s, got %v", d.P50)
+	}
+	if d.P90 != 9*time.Second {
+		t.Errorf("Expected p90 9s, got %v", d.P90)
+	}
+	if d.P99 != 10*time.Second {
+		t.Errorf("Expected p99 10s, got %v", d.P99)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	if d := Summarize(nil); d != (Distribution{}) {
+		t.Errorf("Expected empty distribution, got %+v", d)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	completed := func(id int, typ order.OrderType, created, started int) order.View {
+		return order.View{
+			ID:          id,
+			Type:        typ,
+			Status:      order.COMPLETE,
+			CreatedAt:   start.Add(time.Duration(created) * time.Second),
+			StartedAt:   start.Add(time.Duration(started) * time.Second),
+			CompletedAt: start.Add(time.Duration(started+10) * time.Second),
+		}
+	}
+
+	orders := []order.View{
+		completed(1, order.VIP, 0, 0),
+		completed(2, order.VIP, 5, 10),
+		completed(3, order.Normal, 0, 20),
+		{ID: 4, Type: order.Normal, Status: order.PENDING, CreatedAt: start.Add(30 * time.Second)},
+	}
+
+	r := Build(orders, start.Add(2*time.Minute))
+
+	if r.Completed != 3 {
+		t.Errorf("Expected 3 completed orders, got %d", r.Completed)
+	}
+	if r.Wait[order.VIP].Count != 2 || r.Wait[order.VIP].P99 != 5*time.Second {
+		t.Errorf("Unexpected VIP wait distribution: %+v", r.Wait[order.VIP])
+	}
+	if r.Wait[order.Normal].Count != 1 || r.Wait[order.Normal].Mean != 20*time.Second {
+		t.Errorf("Unexpected Normal wait distribution: %+v", r.Wait[order.Normal])
+	}
+	if r.Cook.Mean != 10*time.Second {
+		t.Errorf("Expected 10s mean cook time, got %v", r.Cook.Mean)
+	}
+	if r.ThroughputPerMinute != 1.5 {
+		t.Errorf("Expected throughput 1.5/min, got %v", r.ThroughputPerMinute)
+	}
+}
@@ -1,268 +1,231 @@
 package main
 
 import (
+	"assignment/internal/archive"
+	"assignment/internal/clock"
+	"assignment/internal/config"
 	"assignment/internal/controller"
-	"assignment/internal/order"
-	"bufio"
+	"assignment/internal/eventlog"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
 var resultFile *os.File
 
+// resultWriter writes structured events to resultFile; nil for the text format
+var resultWriter *eventlog.Writer
+
+// archiveFile receives orders evicted from the archive; nil when export is disabled
+var archiveFile *os.File
+
+// defaultResultPath is where CI expects the order log
+var defaultResultPath = config.Default().ResultPath
+
+// timestampFormat is the layout of times printed by the CLI
+var timestampFormat = config.Default().TimestampFormat
+
+// command is a CLI subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"serve", "run the order controller with the interactive menu (default)", runServe},
+	{"run", "execute a scenario file and fail on unmet expectations", runScenario},
+	{"simulate", "run a deterministic simulation with a virtual clock", runSimulation},
+	{"replay", "rebuild each order's timeline from a result log", runReplay},
+	{"report", "print wait/cook statistics from a result log", runReport},
+	{"export", "convert a result log to JSON lines or CSV", runExport},
+}
+
 func main() {
-	// Open scripts/result.txt for writing (append mode)
-	var err error
-	// Ensure scripts directory exists
-	os.MkdirAll("scripts", 0755)
-	resultFile, err = os.OpenFile("scripts/result.txt", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		fmt.Printf("Warning: Could not open scripts/result.txt: %v\n", err)
-		resultFile = nil
+	args := os.Args[1:]
+
+	// Without a command (or with only flags) the controller is served as before
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && isHelpFlag(args[0]) {
+		printUsage()
+		return
 	}
-	defer func() {
-		if resultFile != nil {
-			resultFile.Close()
-		}
-	}()
 
-	// Create controller with logger that writes to stdout and filtered to result.txt
-	logger := func(msg string) {
-		// Always print to stdout
-		fmt.Println(msg)
-		
-		// Only write order-related events to result.txt
-		if resultFile != nil && isOrderEvent(msg) {
-			fmt.Fprintln(resultFile, msg)
-			resultFile.Sync() // Ensure it's written immediately
-		}
+	if name == "help" {
+		printUsage()
+		return
 	}
-	
-	ctrl := controller.NewController(logger)
-	
-	// Log system initialization (not to result.txt)
-	timestamp := time.Now().Format("15:04:05")
-	fmt.Printf("[%s] System initialized\n", timestamp)
-	fmt.Println("\n=== McDonald's Order Management System ===")
-	
-	scanner := bufio.NewScanner(os.Stdin)
-	
-	for {
-		printMenu()
-		fmt.Print("\nSelect an action: ")
-		
-		if !scanner.Scan() {
-			break
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(args))
 		}
-		
-		choice := strings.TrimSpace(scanner.Text())
-		
-		switch choice {
-		case "1":
-			ctrl.CreateNormalOrder()
-		case "2":
-			ctrl.CreateVIPOrder()
-		case "3":
-			ctrl.AddBot()
-		case "4":
-			if !ctrl.RemoveBot() {
-				fmt.Println("No bots available to remove.")
-			}
-		case "5":
-			printStatus(ctrl)
-		case "6":
-			printSummary(ctrl)
-		case "7":
-			fmt.Println("\nExiting system. Goodbye!")
-			return
-		default:
-			fmt.Println("Invalid choice. Please select 1-7.")
-		}
-		
-		// Small delay for readability
-		time.Sleep(200 * time.Millisecond)
 	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
 }
 
-func printMenu() {
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Println("MENU:")
-	fmt.Println("  1. Create Normal Order")
-	fmt.Println("  2. Create VIP Order")
-	fmt.Println("  3. Add Bot (+ Bot)")
-	fmt.Println("  4. Remove Bot (- Bot)")
-	fmt.Println("  5. View Current Status")
-	fmt.Println("  6. View Summary")
-	fmt.Println("  7. Exit")
-	fmt.Println(strings.Repeat("=", 50))
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
 }
 
-func printStatus(ctrl *controller.Controller) {
-	vipOrders := ctrl.GetVIPOrders()
-	normalOrders := ctrl.GetNormalOrders()
-	_, bots := ctrl.GetState()
-	
-	fmt.Println("\n" + strings.Repeat("-", 50))
-	fmt.Println("CURRENT STATUS")
-	fmt.Println(strings.Repeat("-", 50))
-	
-	// VIP Orders
-	fmt.Println("\nVIP Orders:")
-	if len(vipOrders) == 0 {
-		fmt.Println("  (No VIP orders)")
-	} else {
-		for _, o := range vipOrders {
-			fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
-			if o.Status == order.PROCESSING {
-				fmt.Print(" Processing...")
-			} else if o.Status == order.COMPLETE {
-				fmt.Printf(" (Completed at: %s)", o.CompletedAt.Format("15:04:05"))
-			}
-			fmt.Println()
-		}
+func printUsage() {
+	fmt.Println("Usage: order-manager <command> [flags]")
+	fmt.Println("\nCommands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-10s %s\n", cmd.name, cmd.summary)
 	}
-	
-	// Normal Orders
-	fmt.Println("\nNormal Orders:")
-	if len(normalOrders) == 0 {
-		fmt.Println("  (No Normal orders)")
-	} else {
-		for _, o := range normalOrders {
-			fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
-			if o.Status == order.PROCESSING {
-				fmt.Print(" Processing...")
-			} else if o.Status == order.COMPLETE {
-				fmt.Printf(" (Completed at: %s)", o.CompletedAt.Format("15:04:05"))
-			}
-			fmt.Println()
-		}
+	fmt.Println("\nRun 'order-manager <command> --help' for the flags of a command.")
+}
+
+// usageFor returns the usage text printer for a command's flags
+func usageFor(fs *flag.FlagSet, synopsis string) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: order-manager %s %s\n\nFlags:\n", fs.Name(), synopsis)
+		fs.PrintDefaults()
 	}
-	
-	// Bots
-	fmt.Println("\nBots:")
-	if len(bots) == 0 {
-		fmt.Println("  (No bots)")
-	} else {
-		for _, b := range bots {
-			fmt.Printf("  Bot #%d - Status: %s", b.ID, b.Status)
-			if b.IsProcessing() && b.CurrentOrder != nil {
-				fmt.Printf(" (Processing Order #%d)", b.CurrentOrder.ID)
-			}
-			fmt.Println()
+}
+
+// parseConfig resolves defaults, the optional --config file, environment
+// variables and flags, in that order. bind must register --config on
+// configPath and bind the remaining flags to cfg using its values as defaults.
+func parseConfig(args []string, bind func(cfg *config.Config, configPath *string) *flag.FlagSet) (config.Config, error) {
+	// First pass only finds --config
+	var configPath string
+	probe := config.Default()
+	bind(&probe, &configPath).Parse(args)
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return cfg, err
+	}
+
+	// Second pass applies flags on top of the file and environment
+	bind(&cfg, &configPath).Parse(args)
+	return cfg, cfg.Validate()
+}
+
+// configFlag registers the --config flag shared by the commands
+func configFlag(fs *flag.FlagSet, configPath *string) {
+	fs.StringVar(configPath, "config", "", "JSON, YAML or TOML settings file (environment and flags override it)")
+}
+
+// openResultFile opens the result log for writing in the given format,
+// truncating any previous run
+func openResultFile(path, format string) {
+	var err error
+	// Ensure the directory exists
+	os.MkdirAll(filepath.Dir(path), 0755)
+	resultFile, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		fmt.Printf("Warning: Could not open %s: %v\n", path, err)
+		resultFile = nil
+		return
+	}
+	if format != eventlog.FormatText {
+		resultWriter, err = eventlog.NewWriter(resultFile, format)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
 		}
 	}
-	
-	// Pending counts
-	pending := ctrl.GetPendingOrders()
-	fmt.Printf("\nPending Orders: %d\n", len(pending))
-	fmt.Println(strings.Repeat("-", 50))
 }
 
-func printSummary(ctrl *controller.Controller) {
-	vipOrders := ctrl.GetVIPOrders()
-	normalOrders := ctrl.GetNormalOrders()
-	_, bots := ctrl.GetState()
-	
-	allOrders := make([]*order.Order, 0, len(vipOrders)+len(normalOrders))
-	allOrders = append(allOrders, vipOrders...)
-	allOrders = append(allOrders, normalOrders...)
-	
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Println("SYSTEM SUMMARY")
-	fmt.Println(strings.Repeat("=", 50))
-	
-	fmt.Printf("\nTotal Orders: %d\n", len(allOrders))
-	fmt.Printf("Total Bots: %d\n", len(bots))
-	
-	// Count by status
-	pendingCount := 0
-	processingCount := 0
-	completeCount := 0
-	
-	for _, o := range allOrders {
-		switch o.Status {
-		case order.PENDING:
-			pendingCount++
-		case order.PROCESSING:
-			processingCount++
-		case order.COMPLETE:
-			completeCount++
+// newArchive creates the order archive described by cfg, appending evicted
+// orders to the export file when one is configured
+func newArchive(cfg config.Config) *archive.Store {
+	if cfg.ArchiveExport == "" {
+		return archive.NewStore(cfg.Archive(), nil)
+	}
+	os.MkdirAll(filepath.Dir(cfg.ArchiveExport), 0755)
+	f, err := os.OpenFile(cfg.ArchiveExport, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Printf("Warning: Could not open %s: %v\n", cfg.ArchiveExport, err)
+		return archive.NewStore(cfg.Archive(), nil)
+	}
+	archiveFile = f
+	return archive.NewStore(cfg.Archive(), f)
+}
+
+// recordEvents writes the controller's events to a structured result file
+func recordEvents(ctrl *controller.Controller) {
+	ctrl.Subscribe(func(e controller.Event) {
+		if resultWriter == nil {
+			return
+		}
+		if err := resultWriter.Write(e); err != nil {
+			fmt.Printf("Warning: Could not write result event: %v\n", err)
 		}
+	})
+}
+
+func closeResultFile() {
+	if resultFile != nil {
+		resultFile.Close()
 	}
-	
-	fmt.Printf("\nOrder Status Summary:\n")
-	fmt.Printf("  PENDING: %d\n", pendingCount)
-	fmt.Printf("  PROCESSING: %d\n", processingCount)
-	fmt.Printf("  COMPLETE: %d\n", completeCount)
-	
-	// Count by type
-	normalCount := len(normalOrders)
-	vipCount := len(vipOrders)
-	
-	fmt.Printf("\nOrder Type Summary:\n")
-	fmt.Printf("  Normal: %d\n", normalCount)
-	fmt.Printf("  VIP: %d\n", vipCount)
-	
-	// Bot status
-	idleCount := 0
-	processingBotCount := 0
-	
-	for _, b := range bots {
-		if b.IsIdle() {
-			idleCount++
+	if archiveFile != nil {
+		archiveFile.Close()
+	}
+}
+
+// newLogger returns a controller logger that writes to stdout in the given
+// format ("text" or "json") and filtered, as text, to the result file.
+// Pass the controller's clock: JSON lines are dated by it.
+func newLogger(format string, clk clock.Clock) func(string) {
+	return func(msg string) {
+		// Always print to stdout
+		if format == "json" {
+			fmt.Println(jsonLogLine(msg, clk.Now()))
 		} else {
-			processingBotCount++
+			fmt.Println(msg)
 		}
-	}
-	
-	fmt.Printf("\nBot Status Summary:\n")
-	fmt.Printf("  IDLE: %d\n", idleCount)
-	fmt.Printf("  PROCESSING: %d\n", processingBotCount)
-	
-	// List all orders by type
-	fmt.Printf("\nAll VIP Orders:\n")
-	if len(vipOrders) == 0 {
-		fmt.Println("  (None)")
-	} else {
-		for _, o := range vipOrders {
-			fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
-			if o.Status == order.COMPLETE && !o.CompletedAt.IsZero() {
-				fmt.Printf(" (Completed at: %s)", o.CompletedAt.Format("15:04:05"))
-			}
-			fmt.Println()
+
+		// Only write order-related events to a text result.txt
+		if resultFile != nil && resultWriter == nil && isOrderEvent(msg) {
+			fmt.Fprintln(resultFile, msg)
+			resultFile.Sync() // Ensure it's written immediately
 		}
 	}
-	
-	fmt.Printf("\nAll Normal Orders:\n")
-	if len(normalOrders) == 0 {
-		fmt.Println("  (None)")
-	} else {
-		for _, o := range normalOrders {
-			fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
-			if o.Status == order.COMPLETE && !o.CompletedAt.IsZero() {
-				fmt.Printf(" (Completed at: %s)", o.CompletedAt.Format("15:04:05"))
+}
+
+// jsonLogLine wraps a log message in a JSON object with a full timestamp.
+// The time is the one the message was stamped with; a timestamp format
+// without a date takes the date from now, the clock time of logging.
+func jsonLogLine(msg string, now time.Time) string {
+	at := now
+	// Drop the "[HH:MM:SS] " prefix; the JSON time field replaces it
+	if strings.HasPrefix(msg, "[") {
+		if end := strings.Index(msg, "] "); end > 0 {
+			if t, err := time.ParseInLocation(timestampFormat, msg[1:end], now.Location()); err == nil {
+				at = stampedAt(t, now)
 			}
-			fmt.Println()
+			msg = msg[end+2:]
 		}
 	}
-	
-	// List all bots
-	fmt.Printf("\nAll Bots:\n")
-	if len(bots) == 0 {
-		fmt.Println("  (None)")
-	} else {
-		for _, b := range bots {
-			fmt.Printf("  Bot #%d - Status: %s", b.ID, b.Status)
-			if b.IsProcessing() && b.CurrentOrder != nil {
-				fmt.Printf(" (Processing Order #%d)", b.CurrentOrder.ID)
-			}
-			fmt.Println()
-		}
+	line, _ := json.Marshal(struct {
+		Time    string `json:"time"`
+		Message string `json:"message"`
+	}{at.Format(time.RFC3339), msg})
+	return string(line)
+}
+
+// stampedAt completes a parsed log timestamp with now's date when the
+// timestamp format has none, stepping back a day for a time after now
+func stampedAt(t, now time.Time) time.Time {
+	if t.Year() != 0 {
+		return t
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), now.Location())
+	if at.After(now) {
+		at = at.AddDate(0, 0, -1)
 	}
-	
-	fmt.Println(strings.Repeat("=", 50))
+	return at
 }
 
 // isOrderEvent checks if a log message is order-related and should be written to result.txt
@@ -271,14 +234,31 @@ func printSummary(ctrl *controller.Controller) {
 // 2. Order returned to PENDING
 // 3. Order started processing - Status: PROCESSING
 // 4. Order completed - Status: COMPLETE
+// 5. Order collected or abandoned - Status: COLLECTED / ABANDONED
+// 6. Order scheduled or released - Status: SCHEDULED / PENDING
+// 7. Order waitlisted or admitted - Status: WAITLISTED / PENDING
+// 8. Order held, resumed, moved, promoted or demoted by a manager
 func isOrderEvent(msg string) bool {
 	// Check for order-related messages
 	if strings.Contains(msg, "Order #") {
-		// Include: Order created, Order processing, Order completed, Order returned to PENDING
+		// Include: Order created, Order scheduled, released, waitlisted or admitted, Order processing, Order completed,
+		// Order returned to PENDING, Order collected, Order abandoned
 		if strings.Contains(msg, "created - Status: PENDING") ||
+			strings.Contains(msg, "- Status: SCHEDULED") ||
+			strings.Contains(msg, "released - ") ||
+			strings.Contains(msg, "- Status: WAITLISTED") ||
+			strings.Contains(msg, "admitted - Status: PENDING") ||
+			strings.Contains(msg, "held - Status: HELD") ||
+			strings.Contains(msg, "resumed - Status: PENDING") ||
+			strings.Contains(msg, "moved up - ") ||
+			strings.Contains(msg, "moved down - ") ||
+			strings.Contains(msg, "promoted to VIP") ||
+			strings.Contains(msg, "demoted to Normal") ||
 			strings.Contains(msg, "started processing Order #") ||
 			strings.Contains(msg, "completed by Bot #") ||
-			strings.Contains(msg, "returned to PENDING") {
+			strings.Contains(msg, "returned to PENDING") ||
+			strings.Contains(msg, "- Status: COLLECTED") ||
+			strings.Contains(msg, "- Status: ABANDONED") {
 			return true
 		}
 	}
@@ -11,7 +11,7 @@ echo "Building CLI application..."
 mkdir -p bin
 
 # For Go projects:
-go build -o bin/order-manager main.go
+go build -o bin/order-manager .
 
 # For Node.js projects:
 # npm install
@@ -13,12 +13,13 @@ if [ ! -f bin/order-manager ]; then
     ./scripts/build.sh
 fi
 
-# In CI (no TTY), pipe commands so the app runs and creates scripts/result.txt
+# In CI (no TTY), run the scenario file; unmet expectations fail the script.
+# The run writes scripts/result.txt.
 # In terminal (TTY), run interactively so user sees the menu
 if [ -t 0 ] && [ -t 1 ]; then
     ./bin/order-manager
 else
-    echo -e "1\n1\n2\n3\n5\n7" | ./bin/order-manager > /dev/null 2>&1 || true
+    ./bin/order-manager run --scenario scripts/scenario.txt
 fi
 
 echo "CLI application execution completed"
@@ -8,6 +8,9 @@ echo "Running unit tests..."
 # For Go projects:
 go test ./... -v
 
+# Randomized concurrency tests under the race detector
+go test -race -run 'TestProperty' ./internal/controller/
+
 # For Node.js projects:
 # npm test
 
@@ -0,0 +1,895 @@
+package main
+
+import (
+	"assignment/internal/archive"
+	"assignment/internal/autoscaler"
+	"assignment/internal/bot"
+	"assignment/internal/clock"
+	"assignment/internal/config"
+	"assignment/internal/controller"
+	"assignment/internal/metrics"
+	"assignment/internal/order"
+	"assignment/internal/roster"
+	"assignment/internal/stats"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// serveFlags binds the serve flags to cfg, using its current values as defaults
+func serveFlags(cfg *config.Config, configPath *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = usageFor(fs, "[flags]")
+	configFlag(fs, configPath)
+	fs.StringVar(&cfg.ResultPath, "result", cfg.ResultPath, "file the order log is written to")
+	fs.StringVar(&cfg.ResultFormat, "result-format", cfg.ResultFormat, "order log format: text, json or csv")
+	fs.IntVar(&cfg.Bots, "bots", cfg.Bots, "number of bots to start with")
+	fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "stdout log format: text or json")
+	fs.DurationVar(&cfg.ProcessingTime, "processing-time", cfg.ProcessingTime, "time a bot takes per order")
+	fs.DurationVar(&cfg.PickupTimeout, "pickup-timeout", cfg.PickupTimeout, "mark completed orders ABANDONED when not collected within this (0 disables)")
+	fs.DurationVar(&cfg.ReleaseLeadTime, "release-lead-time", cfg.ReleaseLeadTime, "release scheduled orders into the queue this long before they are due")
+	fs.IntVar(&cfg.MaxPendingVIP, "max-pending-vip", cfg.MaxPendingVIP, "VIP orders allowed to wait before new ones are turned away (0 for no limit)")
+	fs.IntVar(&cfg.MaxPendingNormal, "max-pending-normal", cfg.MaxPendingNormal, "Normal orders allowed to wait before new ones are turned away (0 for no limit)")
+	fs.IntVar(&cfg.MaxPending, "max-pending", cfg.MaxPending, "orders allowed to wait before new ones are turned away (0 for no limit)")
+	fs.BoolVar(&cfg.Waitlist, "waitlist", cfg.Waitlist, "waitlist new orders over the limits instead of rejecting them")
+	fs.IntVar(&cfg.MaxWaitlist, "max-waitlist", cfg.MaxWaitlist, "orders allowed on the waitlist before new ones are rejected (0 for no limit)")
+	fs.IntVar(&cfg.BusyThreshold, "busy-threshold", cfg.BusyThreshold, "percentage of the queue limits at which the kitchen is reported busy (0 disables)")
+	fs.IntVar(&cfg.ArchiveMaxOrders, "archive-max-orders", cfg.ArchiveMaxOrders, "collected and abandoned orders kept in memory (0 for no limit)")
+	fs.DurationVar(&cfg.ArchiveMaxAge, "archive-max-age", cfg.ArchiveMaxAge, "how long collected and abandoned orders are kept in memory (0 for no limit)")
+	fs.StringVar(&cfg.ArchiveExport, "archive-export", cfg.ArchiveExport, "JSON-lines file orders are appended to before they leave the archive")
+	fs.BoolVar(&cfg.CheckInvariants, "check-invariants", cfg.CheckInvariants, "verify scheduling rules on every transition and report violations")
+	fs.StringVar(&cfg.MetricsAddr, "metrics-addr", cfg.MetricsAddr, "serve Prometheus metrics on this address (e.g. :9090)")
+	fs.BoolVar(&cfg.Autoscale, "autoscale", cfg.Autoscale, "automatically add and remove bots based on queue depth and wait time")
+	fs.IntVar(&cfg.MinBots, "min-bots", cfg.MinBots, "minimum number of bots kept by the autoscaler")
+	fs.IntVar(&cfg.MaxBots, "max-bots", cfg.MaxBots, "maximum number of bots allowed by the autoscaler")
+	fs.StringVar(&cfg.RosterPath, "roster", cfg.RosterPath, "JSON shift schedule that sets the number of bots by time of day")
+	fs.BoolVar(&cfg.Menu, "menu", cfg.Menu, "read menu choices from stdin; with --menu=false run until interrupted")
+	return fs
+}
+
+// runServe implements "serve": the controller driven by the interactive menu,
+// or running unattended with --menu=false
+func runServe(args []string) int {
+	cfg, err := parseConfig(args, serveFlags)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	var schedule *roster.Roster
+	if cfg.RosterPath != "" {
+		schedule, err = roster.Load(cfg.RosterPath)
+		if err != nil {
+			fmt.Println(err)
+			return 2
+		}
+	}
+
+	timestampFormat = cfg.TimestampFormat
+	openResultFile(cfg.ResultPath, cfg.ResultFormat)
+	defer closeResultFile()
+
+	clk := clock.Real()
+	logger := newLogger(cfg.LogFormat, clk)
+	ctrl := controller.NewController(logger, controller.WithConfig(cfg.Controller()), controller.WithClock(clk), controller.WithArchive(newArchive(cfg)))
+	recordEvents(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.NewCollector(ctrl))
+		server := &http.Server{Addr: cfg.MetricsAddr, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Printf("Warning: metrics server stopped: %v\n", err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(ctx)
+		}()
+	}
+
+	for i := 0; i < cfg.Bots; i++ {
+		ctrl.AddBot()
+	}
+
+	if cfg.Autoscale {
+		scaling := autoscaler.DefaultConfig()
+		scaling.MinBots = cfg.MinBots
+		scaling.MaxBots = cfg.MaxBots
+		scaler, err := autoscaler.New(scaling, ctrl, logger, autoscaler.WithTimestampFormat(cfg.TimestampFormat))
+		if err != nil {
+			fmt.Println(err)
+			return 2
+		}
+		go scaler.Run(ctx)
+	}
+
+	if schedule != nil {
+		go roster.NewEnforcer(schedule, ctrl, logger, roster.WithTimestampFormat(cfg.TimestampFormat)).Run(ctx, time.Second)
+	}
+
+	// Log system initialization (not to result.txt)
+	timestamp := time.Now().Format(timestampFormat)
+	fmt.Printf("[%s] System initialized\n", timestamp)
+
+	if !cfg.Menu {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-ctx.Done()
+		fmt.Println("\nShutting down.")
+		ctrl.Shutdown()
+		return 0
+	}
+
+	runMenu(ctrl, cfg.MenuDelay)
+	ctrl.Shutdown()
+	return 0
+}
+
+// userMessage turns a controller error into a message for the person at the menu
+func userMessage(err error) string {
+	switch {
+	case errors.Is(err, controller.ErrNoBots):
+		return "No bots available to remove."
+	case errors.Is(err, controller.ErrOrderNotFound):
+		return "Order not found."
+	case errors.Is(err, controller.ErrInvalidTransition):
+		return "That action is not allowed in the order's current state."
+	case errors.Is(err, controller.ErrOrderCooked):
+		return "That order is already cooked."
+	case errors.Is(err, controller.ErrCannotMove):
+		return "That order cannot be moved any further in its queue."
+	case errors.Is(err, controller.ErrCannotChangeType):
+		return "Only orders still waiting in the queue can change type."
+	case errors.Is(err, controller.ErrDueNotInFuture):
+		return "The due time must be later than now."
+	case errors.Is(err, controller.ErrQueueFull):
+		return "The kitchen queue is full, please try again later."
+	case errors.Is(err, controller.ErrShutDown):
+		return "The system is shutting down."
+	default:
+		return err.Error()
+	}
+}
+
+// readOrderID prompts for an order ID on the menu's input
+func readOrderID(scanner *bufio.Scanner) (int, bool) {
+	fmt.Print("Order ID: ")
+	if !scanner.Scan() {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "#"))
+	if err != nil {
+		fmt.Println("Invalid order ID.")
+		return 0, false
+	}
+	return id, true
+}
+
+// runMenu reads menu choices from stdin until exit or end of input
+func runMenu(ctrl *controller.Controller, delay time.Duration) {
+	fmt.Println("\n=== McDonald's Order Management System ===")
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	for {
+		printMenu()
+		fmt.Print("\nSelect an action: ")
+
+		if !scanner.Scan() {
+			break
+		}
+
+		choice := strings.TrimSpace(scanner.Text())
+
+		var err error
+		switch choice {
+		case "1":
+			err = createOrder(ctrl, ctrl.CreateNormalOrder)
+		case "2":
+			err = createOrder(ctrl, ctrl.CreateVIPOrder)
+		case "3":
+			_, err = ctrl.AddBot()
+		case "4":
+			_, err = ctrl.RemoveBot()
+		case "5":
+			printStatus(ctrl)
+		case "6":
+			printSummary(ctrl)
+		case "7":
+			fmt.Println("\nExiting system. Goodbye!")
+			return
+		case "8":
+			printBotReport(ctrl)
+		case "9":
+			if id, ok := readOrderID(scanner); ok {
+				err = printOrderHistory(ctrl, id)
+			}
+		case "10":
+			if id, ok := readOrderID(scanner); ok {
+				_, err = ctrl.CollectOrder(id)
+			}
+		case "11":
+			if id, ok := readOrderID(scanner); ok {
+				err = printOrderPosition(ctrl, id)
+			}
+		case "12":
+			err = searchOrders(ctrl, scanner)
+		case "13":
+			err = promiseOrder(ctrl, scanner)
+		case "14":
+			err = scheduleOrder(ctrl, scanner)
+		case "15":
+			if id, ok := readOrderID(scanner); ok {
+				err = toggleHold(ctrl, id)
+			}
+		case "16":
+			err = moveOrder(ctrl, scanner)
+		case "17":
+			if id, ok := readOrderID(scanner); ok {
+				err = toggleType(ctrl, id)
+			}
+		default:
+			fmt.Println("Invalid choice. Please select 1-17.")
+		}
+		if err != nil {
+			fmt.Println(userMessage(err))
+		}
+
+		// Small delay for readability
+		time.Sleep(delay)
+	}
+}
+
+func printMenu() {
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("MENU:")
+	fmt.Println("  1. Create Normal Order")
+	fmt.Println("  2. Create VIP Order")
+	fmt.Println("  3. Add Bot (+ Bot)")
+	fmt.Println("  4. Remove Bot (- Bot)")
+	fmt.Println("  5. View Current Status")
+	fmt.Println("  6. View Summary")
+	fmt.Println("  7. Exit")
+	fmt.Println("  8. Bot Report")
+	fmt.Println("  9. Order History")
+	fmt.Println("  10. Collect Order")
+	fmt.Println("  11. Find Order")
+	fmt.Println("  12. Search Orders")
+	fmt.Println("  13. Set Promised Time")
+	fmt.Println("  14. Schedule Order")
+	fmt.Println("  15. Hold / Resume Order")
+	fmt.Println("  16. Move Order")
+	fmt.Println("  17. Upgrade to VIP / Downgrade to Normal")
+	fmt.Println(strings.Repeat("=", 50))
+}
+
+func printStatus(ctrl *controller.Controller) {
+	vipOrders := ctrl.GetVIPOrders()
+	normalOrders := ctrl.GetNormalOrders()
+	_, bots := ctrl.GetState()
+
+	fmt.Println("\n" + strings.Repeat("-", 50))
+	fmt.Println("CURRENT STATUS")
+	fmt.Println(strings.Repeat("-", 50))
+
+	// Orders still in the kitchen, by type, with the expected completion of pending ones
+	readyIn := make(map[int]time.Duration)
+	for _, e := range ctrl.GetPendingETAs() {
+		readyIn[e.Order.ID] = e.ReadyIn
+	}
+	printKitchenOrders("VIP Orders", "(No VIP orders)", vipOrders, readyIn)
+	printKitchenOrders("Normal Orders", "(No Normal orders)", normalOrders, readyIn)
+
+	// Pre-orders not yet released into the queue
+	if scheduled := ctrl.GetScheduledOrders(); len(scheduled) > 0 {
+		fmt.Println("\nScheduled Orders:")
+		for _, o := range scheduled {
+			fmt.Printf("  Order #%d (%s) - Due at: %s\n", o.ID, o.Type, o.DueAt.Format(timestampFormat))
+		}
+	}
+
+	// Orders accepted while the queue was full, in the order they will be admitted
+	if waitlisted := ctrl.GetWaitlistedOrders(); len(waitlisted) > 0 {
+		fmt.Println("\nWaitlist:")
+		for _, o := range waitlisted {
+			fmt.Printf("  Order #%d (%s) - Since: %s\n", o.ID, o.Type, o.CreatedAt.Format(timestampFormat))
+		}
+	}
+
+	// The pickup area only shows orders waiting for their customer
+	fmt.Println("\nAwaiting Pickup:")
+	complete := ctrl.GetCompleteOrders()
+	if len(complete) == 0 {
+		fmt.Println("  (No orders awaiting pickup)")
+	}
+	for _, o := range complete {
+		fmt.Printf("  Order #%d (%s) - Completed at: %s\n", o.ID, o.Type, o.CompletedAt.Format(timestampFormat))
+	}
+	if abandoned := ctrl.GetAbandonedOrders(); len(abandoned) > 0 {
+		fmt.Println("\nAbandoned (not collected):")
+		for _, o := range abandoned {
+			fmt.Printf("  Order #%d (%s) - Completed at: %s\n", o.ID, o.Type, o.CompletedAt.Format(timestampFormat))
+		}
+	}
+
+	// Bots
+	fmt.Println("\nBots:")
+	if len(bots) == 0 {
+		fmt.Println("  (No bots)")
+	} else {
+		for _, b := range bots {
+			fmt.Printf("  Bot #%d - Status: %s", b.ID, b.Status)
+			if b.IsProcessing() {
+				fmt.Printf(" (Processing Order #%d)", b.OrderID)
+			}
+			fmt.Println()
+		}
+	}
+
+	// Pending counts
+	pending := ctrl.GetPendingOrders()
+	fmt.Printf("\nPending Orders: %d\n", len(pending))
+	if ctrl.IsKitchenBusy() {
+		fmt.Println("Kitchen is busy: expect longer waits")
+	}
+	fmt.Println(strings.Repeat("-", 50))
+}
+
+// printKitchenOrders lists the pending and processing orders among orders,
+// with the ETA of pending ones when known
+func printKitchenOrders(title, empty string, orders []order.View, readyIn map[int]time.Duration) {
+	fmt.Printf("\n%s:\n", title)
+	shown := 0
+	for _, o := range orders {
+		if o.Status.IsCooked() {
+			continue
+		}
+		fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
+		if o.Status == order.PROCESSING {
+			fmt.Print(" Processing...")
+		} else if o.Status == order.HELD {
+			fmt.Print(" (on hold)")
+		} else if eta, ok := readyIn[o.ID]; ok {
+			fmt.Printf(" (ready in %s)", formatETA(eta))
+		} else {
+			fmt.Print(" (waiting for a bot)")
+		}
+		fmt.Println()
+		shown++
+	}
+	if shown == 0 {
+		fmt.Println("  " + empty)
+	}
+}
+
+func printSummary(ctrl *controller.Controller) {
+	vipOrders := ctrl.GetVIPOrders()
+	normalOrders := ctrl.GetNormalOrders()
+	_, bots := ctrl.GetState()
+
+	// Scheduled and waitlisted orders and the collected and abandoned orders
+	// still in the archive count towards the totals
+	scheduled := ctrl.GetScheduledOrders()
+	waitlisted := ctrl.GetWaitlistedOrders()
+	archived := ctrl.GetArchivedOrders(archive.Query{}).Records
+	allOrders := make([]order.View, 0, len(vipOrders)+len(normalOrders)+len(scheduled)+len(waitlisted)+len(archived))
+	allOrders = append(allOrders, vipOrders...)
+	allOrders = append(allOrders, normalOrders...)
+	allOrders = append(allOrders, scheduled...)
+	allOrders = append(allOrders, waitlisted...)
+	for _, r := range archived {
+		allOrders = append(allOrders, r.View)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("SYSTEM SUMMARY")
+	fmt.Println(strings.Repeat("=", 50))
+
+	fmt.Printf("\nTotal Orders: %d\n", len(allOrders))
+	fmt.Printf("Total Bots: %d\n", len(bots))
+
+	// Count by status
+	scheduledCount := 0
+	waitlistedCount := 0
+	pendingCount := 0
+	heldCount := 0
+	processingCount := 0
+	completeCount := 0
+	collectedCount := 0
+	abandonedCount := 0
+
+	for _, o := range allOrders {
+		switch o.Status {
+		case order.SCHEDULED:
+			scheduledCount++
+		case order.WAITLISTED:
+			waitlistedCount++
+		case order.PENDING:
+			pendingCount++
+		case order.HELD:
+			heldCount++
+		case order.PROCESSING:
+			processingCount++
+		case order.COMPLETE:
+			completeCount++
+		case order.COLLECTED:
+			collectedCount++
+		case order.ABANDONED:
+			abandonedCount++
+		}
+	}
+
+	fmt.Printf("\nOrder Status Summary:\n")
+	fmt.Printf("  SCHEDULED: %d\n", scheduledCount)
+	fmt.Printf("  WAITLISTED: %d\n", waitlistedCount)
+	fmt.Printf("  PENDING: %d\n", pendingCount)
+	fmt.Printf("  HELD: %d\n", heldCount)
+	fmt.Printf("  PROCESSING: %d\n", processingCount)
+	fmt.Printf("  COMPLETE: %d\n", completeCount)
+	fmt.Printf("  COLLECTED: %d\n", collectedCount)
+	fmt.Printf("  ABANDONED: %d\n", abandonedCount)
+
+	// Count by type
+	normalCount := 0
+	vipCount := 0
+	for _, o := range allOrders {
+		if o.IsVIP() {
+			vipCount++
+		} else {
+			normalCount++
+		}
+	}
+
+	fmt.Printf("\nOrder Type Summary:\n")
+	fmt.Printf("  Normal: %d\n", normalCount)
+	fmt.Printf("  VIP: %d\n", vipCount)
+
+	sla := ctrl.GetSLAStats()
+	fmt.Printf("\nPromised Time Summary:\n")
+	fmt.Printf("  Promised: %d\n", sla.Promised)
+	fmt.Printf("  At risk warnings: %d\n", sla.AtRisk)
+	fmt.Printf("  Breached: %d\n", sla.Breached)
+
+	// Timing statistics (completed orders only)
+	report := stats.Build(allOrders, time.Now())
+
+	fmt.Printf("\nWait Time Summary (completed orders):\n")
+	printDistribution("VIP", report.Wait[order.VIP])
+	printDistribution("Normal", report.Wait[order.Normal])
+	printDistribution("All", report.AllWait)
+
+	fmt.Printf("\nCook Time Summary:\n")
+	printDistribution("All", report.Cook)
+
+	fmt.Printf("\nThroughput: %.2f orders/min\n", report.ThroughputPerMinute)
+
+	// Bot status
+	idleCount := 0
+	processingBotCount := 0
+
+	for _, b := range bots {
+		if b.IsIdle() {
+			idleCount++
+		} else {
+			processingBotCount++
+		}
+	}
+
+	fmt.Printf("\nBot Status Summary:\n")
+	fmt.Printf("  IDLE: %d\n", idleCount)
+	fmt.Printf("  PROCESSING: %d\n", processingBotCount)
+
+	// List all orders by type
+	fmt.Printf("\nAll VIP Orders:\n")
+	if len(vipOrders) == 0 {
+		fmt.Println("  (None)")
+	} else {
+		for _, o := range vipOrders {
+			fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
+			if o.Status.IsCooked() && !o.CompletedAt.IsZero() {
+				fmt.Printf(" (Completed at: %s)", o.CompletedAt.Format(timestampFormat))
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Printf("\nAll Normal Orders:\n")
+	if len(normalOrders) == 0 {
+		fmt.Println("  (None)")
+	} else {
+		for _, o := range normalOrders {
+			fmt.Printf("  Order #%d - Status: %s", o.ID, o.Status)
+			if o.Status.IsCooked() && !o.CompletedAt.IsZero() {
+				fmt.Printf(" (Completed at: %s)", o.CompletedAt.Format(timestampFormat))
+			}
+			fmt.Println()
+		}
+	}
+
+	// List all bots
+	fmt.Printf("\nAll Bots:\n")
+	if len(bots) == 0 {
+		fmt.Println("  (None)")
+	} else {
+		for _, b := range bots {
+			fmt.Printf("  Bot #%d - Status: %s", b.ID, b.Status)
+			if b.IsProcessing() {
+				fmt.Printf(" (Processing Order #%d)", b.OrderID)
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Println(strings.Repeat("=", 50))
+}
+
+func printBotReport(ctrl *controller.Controller) {
+	botStats := ctrl.GetBotStats()
+
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("BOT REPORT")
+	fmt.Println(strings.Repeat("=", 50))
+
+	if len(botStats) == 0 {
+		fmt.Println("  (No bots)")
+		fmt.Println(strings.Repeat("=", 50))
+		return
+	}
+
+	var total bot.Stats
+	for _, s := range botStats {
+		state := "active"
+		if !s.RemovedAt.IsZero() {
+			state = "removed at " + s.RemovedAt.Format(timestampFormat)
+		}
+		fmt.Printf("  Bot #%d (%s)\n", s.ID, state)
+		fmt.Printf("    Uptime: %s  Busy: %s  Idle: %s  Utilisation: %.1f%%\n",
+			formatDuration(s.Uptime), formatDuration(s.BusyTime), formatDuration(s.IdleTime), s.Utilisation()*100)
+		fmt.Printf("    Orders completed: %d  Orders interrupted: %d\n", s.OrdersCompleted, s.OrdersInterrupted)
+
+		total.Uptime += s.Uptime
+		total.BusyTime += s.BusyTime
+		total.IdleTime += s.IdleTime
+		total.OrdersCompleted += s.OrdersCompleted
+		total.OrdersInterrupted += s.OrdersInterrupted
+	}
+
+	fmt.Printf("\nTotals (%d bots):\n", len(botStats))
+	fmt.Printf("  Uptime: %s  Busy: %s  Idle: %s  Utilisation: %.1f%%\n",
+		formatDuration(total.Uptime), formatDuration(total.BusyTime), formatDuration(total.IdleTime), total.Utilisation()*100)
+	fmt.Printf("  Orders completed: %d  Orders interrupted: %d\n", total.OrdersCompleted, total.OrdersInterrupted)
+	fmt.Println(strings.Repeat("=", 50))
+}
+
+// printOrderHistory prints every status change of one order
+func printOrderHistory(ctrl *controller.Controller, id int) error {
+	o, err := ctrl.GetOrder(id)
+	if err != nil {
+		return err
+	}
+	history, err := ctrl.GetOrderHistory(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Printf("ORDER #%d HISTORY (%s, now %s)\n", o.ID, o.Type, o.Status)
+	fmt.Println(strings.Repeat("=", 50))
+	created := o.Status
+	if len(history) > 0 {
+		created = history[0].From
+	}
+	fmt.Printf("  [%s] created - %s\n", o.CreatedAt.Format(timestampFormat), created)
+	for _, t := range history {
+		actor := ""
+		if t.BotID != 0 {
+			actor = fmt.Sprintf(" by Bot #%d", t.BotID)
+		}
+		if t.From == t.To {
+			fmt.Printf("  [%s] %s%s (%s)\n", t.At.Format(timestampFormat), t.To, actor, t.Reason)
+			continue
+		}
+		fmt.Printf("  [%s] %s -> %s%s (%s)\n", t.At.Format(timestampFormat), t.From, t.To, actor, t.Reason)
+	}
+	fmt.Println(strings.Repeat("=", 50))
+	return nil
+}
+
+// printOrderPosition answers "where is my order?"
+func printOrderPosition(ctrl *controller.Controller, id int) error {
+	p, err := ctrl.GetOrderPosition(id)
+	if err != nil {
+		return err
+	}
+	o := p.Order
+
+	fmt.Printf("\nOrder #%d (%s) - Status: %s\n", o.ID, o.Type, o.Status)
+	if !o.PromisedBy.IsZero() {
+		fmt.Printf("  Promised by %s\n", o.PromisedBy.Format(timestampFormat))
+	}
+	switch o.Status {
+	case order.WAITLISTED:
+		fmt.Println("  On the waitlist: joins the queue as soon as there is room")
+	case order.PENDING:
+		fmt.Printf("  Place in queue: %d (%d ahead)\n", p.Place, p.Ahead)
+		if p.Estimated {
+			fmt.Printf("  Estimated wait: ~%s, ready in %s\n", formatDuration(p.EstimatedWait), formatETA(p.EstimatedReady))
+		} else {
+			fmt.Println("  Estimated wait: unknown, no bots available")
+		}
+	case order.PROCESSING:
+		_, bots := ctrl.GetState()
+		for _, b := range bots {
+			if b.OrderID == o.ID {
+				fmt.Printf("  Being prepared by Bot #%d since %s\n", b.ID, o.StartedAt.Format(timestampFormat))
+				return nil
+			}
+		}
+		// The order finished or was returned since the position was taken
+		fmt.Printf("  Being prepared since %s\n", o.StartedAt.Format(timestampFormat))
+	case order.COMPLETE:
+		fmt.Printf("  Ready for pickup since %s\n", o.CompletedAt.Format(timestampFormat))
+	case order.COLLECTED:
+		fmt.Printf("  Collected at %s\n", o.CollectedAt.Format(timestampFormat))
+	case order.ABANDONED:
+		fmt.Printf("  Not collected; ready since %s\n", o.CompletedAt.Format(timestampFormat))
+	}
+	return nil
+}
+
+// promiseOrder asks for an order and the time it was promised by
+func promiseOrder(ctrl *controller.Controller, scanner *bufio.Scanner) error {
+	id, ok := readOrderID(scanner)
+	if !ok {
+		return nil
+	}
+	fmt.Print("Promised by (HH:MM[:SS], blank to withdraw): ")
+	if !scanner.Scan() {
+		return nil
+	}
+	var by time.Time
+	if text := strings.TrimSpace(scanner.Text()); text != "" {
+		var err error
+		if by, err = parseClockTime(text, time.Now()); err != nil {
+			fmt.Println("Invalid time.")
+			return nil
+		}
+	}
+	o, err := ctrl.SetPromisedBy(id, by)
+	if err != nil {
+		return err
+	}
+	if by.IsZero() {
+		fmt.Printf("Order #%d has no promised time.\n", o.ID)
+	} else {
+		fmt.Printf("Order #%d promised by %s.\n", o.ID, by.Format(timestampFormat))
+	}
+	return nil
+}
+
+// createOrder creates an order and tells the customer if it was waitlisted
+// or the kitchen is busy
+func createOrder(ctrl *controller.Controller, create func() (order.View, error)) error {
+	o, err := create()
+	if err != nil {
+		return err
+	}
+	if o.Status == order.WAITLISTED {
+		fmt.Printf("The kitchen queue is full: Order #%d is on the waitlist and joins the queue as soon as there is room.\n", o.ID)
+	} else if ctrl.IsKitchenBusy() {
+		fmt.Println("The kitchen is busy right now, so your order may take longer than usual.")
+	}
+	return nil
+}
+
+// scheduleOrder asks for an order type and due time and schedules the order
+func scheduleOrder(ctrl *controller.Controller, scanner *bufio.Scanner) error {
+	fmt.Print("Order type (Normal/VIP): ")
+	if !scanner.Scan() {
+		return nil
+	}
+	typ, ok := parseOrderType(strings.TrimSpace(scanner.Text()))
+	if !ok {
+		fmt.Println("Invalid order type.")
+		return nil
+	}
+	fmt.Print("Due at (HH:MM[:SS]): ")
+	if !scanner.Scan() {
+		return nil
+	}
+	due, err := parseClockTime(strings.TrimSpace(scanner.Text()), time.Now())
+	if err != nil {
+		fmt.Println("Invalid time.")
+		return nil
+	}
+	_, err = ctrl.ScheduleOrder(typ, due)
+	return err
+}
+
+// toggleHold puts a pending order on hold, or resumes a held one
+func toggleHold(ctrl *controller.Controller, id int) error {
+	o, err := ctrl.GetOrder(id)
+	if err != nil {
+		return err
+	}
+	if o.Status == order.HELD {
+		_, err = ctrl.ResumeOrder(id)
+	} else {
+		_, err = ctrl.HoldOrder(id)
+	}
+	return err
+}
+
+// toggleType promotes a Normal order to VIP, or demotes a VIP order
+func toggleType(ctrl *controller.Controller, id int) error {
+	o, err := ctrl.GetOrder(id)
+	if err != nil {
+		return err
+	}
+	if o.IsVIP() {
+		_, err = ctrl.DemoteOrder(id)
+	} else {
+		_, err = ctrl.PromoteOrder(id)
+	}
+	return err
+}
+
+// moveOrder asks for an order and moves it one place up or down its queue
+func moveOrder(ctrl *controller.Controller, scanner *bufio.Scanner) error {
+	id, ok := readOrderID(scanner)
+	if !ok {
+		return nil
+	}
+	fmt.Print("Direction (up/down): ")
+	if !scanner.Scan() {
+		return nil
+	}
+	var err error
+	switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+	case "up", "u":
+		_, err = ctrl.MoveOrderUp(id)
+	case "down", "d":
+		_, err = ctrl.MoveOrderDown(id)
+	default:
+		fmt.Println("Invalid direction.")
+	}
+	return err
+}
+
+// searchPageSize is the number of orders listed per page by Search Orders
+const searchPageSize = 10
+
+// searchOrders prompts for filters and lists matching orders a page at a time
+func searchOrders(ctrl *controller.Controller, scanner *bufio.Scanner) error {
+	fmt.Print("Filters (e.g. status=PENDING,PROCESSING type=VIP bot=2 from=12:00 to=13:30; blank for all): ")
+	if !scanner.Scan() {
+		return nil
+	}
+	q, err := parseOrderQuery(scanner.Text(), time.Now())
+	if err != nil {
+		return err
+	}
+	q.Limit = searchPageSize
+
+	fmt.Println()
+	for {
+		page := ctrl.QueryOrders(q)
+		if len(page.Orders) == 0 && q.After == 0 {
+			fmt.Println("  (No matching orders)")
+		}
+		for _, o := range page.Orders {
+			fmt.Printf("  Order #%d (%s) - Status: %s - Created at: %s\n", o.ID, o.Type, o.Status, o.CreatedAt.Format(timestampFormat))
+		}
+		if page.Next == 0 {
+			return nil
+		}
+		fmt.Print("More? (y/N): ")
+		if !scanner.Scan() || !strings.EqualFold(strings.TrimSpace(scanner.Text()), "y") {
+			return nil
+		}
+		q.After = page.Next
+	}
+}
+
+// parseOrderQuery parses space-separated key=value filters. Times are
+// HH:MM[:SS] on the day of now, or RFC 3339.
+func parseOrderQuery(text string, now time.Time) (controller.Query, error) {
+	var q controller.Query
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return q, fmt.Errorf("invalid filter %q, expected key=value", field)
+		}
+		var err error
+		switch key {
+		case "status":
+			for _, name := range strings.Split(value, ",") {
+				status, ok := order.ParseStatus(strings.ToUpper(name))
+				if !ok {
+					return q, fmt.Errorf("unknown status %q", name)
+				}
+				q.Statuses = append(q.Statuses, status)
+			}
+		case "type":
+			for _, name := range strings.Split(value, ",") {
+				typ, ok := parseOrderType(name)
+				if !ok {
+					return q, fmt.Errorf("unknown order type %q", name)
+				}
+				q.Types = append(q.Types, typ)
+			}
+		case "bot":
+			q.BotID, err = strconv.Atoi(strings.TrimPrefix(value, "#"))
+		case "from":
+			q.CreatedFrom, err = parseClockTime(value, now)
+		case "to":
+			q.CreatedTo, err = parseClockTime(value, now)
+		default:
+			return q, fmt.Errorf("unknown filter %q", key)
+		}
+		if err != nil {
+			return q, fmt.Errorf("invalid %s filter %q", key, value)
+		}
+	}
+	return q, nil
+}
+
+// parseOrderType accepts order type names in any case
+func parseOrderType(name string) (order.OrderType, bool) {
+	for _, t := range []order.OrderType{order.Normal, order.VIP} {
+		if strings.EqualFold(t.String(), name) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// parseClockTime reads HH:MM or HH:MM:SS on the day of now, or an RFC 3339 time
+func parseClockTime(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// printDistribution prints one line of wait/cook time statistics
+func printDistribution(label string, d stats.Distribution) {
+	if d.Count == 0 {
+		fmt.Printf("  %s: (no completed orders)\n", label)
+		return
+	}
+	fmt.Printf("  %s: n=%d mean=%s p50=%s p90=%s p99=%s\n", label, d.Count,
+		formatDuration(d.Mean), formatDuration(d.P50), formatDuration(d.P90), formatDuration(d.P99))
+}
+
+// formatETA rounds an estimate the way it is told to customers: to the
+// second under a minute, otherwise up to whole minutes
+func formatETA(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("~%ds", int(d.Round(time.Second).Seconds()))
+	}
+	return fmt.Sprintf("~%d min", int((d+time.Minute-1)/time.Minute))
+}
+
+// formatDuration rounds durations for display
+func formatDuration(d time.Duration) string {
+	return d.Round(100 * time.Millisecond).String()
+}